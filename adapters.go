@@ -0,0 +1,65 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GomockMatcher is the interface of gomock.Matcher, declared here so that the
+// matchers of github.com/golang/mock and go.uber.org/mock can be used without
+// depending on them.
+type GomockMatcher interface {
+	Matches(x any) bool
+	String() string
+}
+
+// GomegaMatcher is the matching part of the interface of gomega's
+// types.GomegaMatcher, declared here so that gomega matchers can be used
+// without depending on gomega.
+type GomegaMatcher interface {
+	Match(actual any) (bool, error)
+}
+
+// MatchGomock returns a Matcher function for MockResp which applies the
+// gomock matcher to the value extracted from the request, e.g. by BodyString.
+// With a nil value function, the matcher is applied to the request itself.
+func MatchGomock(value func(req *http.Request) any, matcher GomockMatcher) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		return matcher.Matches(extract(value, req))
+	}
+}
+
+// MatchGomega returns a Matcher function for MockResp which applies the
+// gomega matcher to the value extracted from the request, e.g. by BodyJSON.
+// With a nil value function, the matcher is applied to the request itself.  A
+// matcher which fails with an error doesn't match.
+func MatchGomega(value func(req *http.Request) any, matcher GomegaMatcher) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		ok, err := matcher.Match(extract(value, req))
+		return err == nil && ok
+	}
+}
+
+func extract(value func(req *http.Request) any, req *http.Request) any {
+	if value == nil {
+		return req
+	}
+	return value(req)
+}
+
+// BodyString returns the body of the request as a string, compressed bodies
+// are decompressed.
+func BodyString(req *http.Request) any {
+	return string(payload(req))
+}
+
+// BodyJSON returns the JSON body of the request decoded into maps, slices and
+// float64 numbers like json.Unmarshal into an interface value, or nil if the
+// body isn't JSON.
+func BodyJSON(req *http.Request) any {
+	var v any
+	if err := json.Unmarshal(payload(req), &v); err != nil {
+		return nil
+	}
+	return v
+}
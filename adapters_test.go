@@ -0,0 +1,52 @@
+package mockresponder
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eqMatcher is a gomock style matcher.
+type eqMatcher struct{ want any }
+
+func (m eqMatcher) Matches(x any) bool { return fmt.Sprint(x) == fmt.Sprint(m.want) }
+func (m eqMatcher) String() string     { return fmt.Sprintf("is equal to %v", m.want) }
+
+// keyMatcher is a gomega style matcher.
+type keyMatcher struct{ key string }
+
+func (m keyMatcher) Match(actual any) (bool, error) {
+	obj, ok := actual.(map[string]any)
+	if !ok {
+		return false, errors.New("not a map")
+	}
+	_, ok = obj[m.key]
+	return ok, nil
+}
+
+func TestMockResponder_MatcherAdapters(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Matcher: MatchGomega(BodyJSON, keyMatcher{"name"}), Data: []byte(`gomega`)},
+		MockResp{Matcher: MatchGomock(BodyString, eqMatcher{"ping"}), Data: []byte(`gomock`)},
+		MockResp{Matcher: MatchGomock(nil, eqMatcher{"never"}), Data: []byte(`never`)},
+	})
+
+	post := func(body string) int {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/bla", strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, post(`ping`))
+	assert.Equal(t, http.StatusOK, post(`{"name": "r1"}`))
+
+	req, _ := http.NewRequest(http.MethodPost, "/bla", strings.NewReader(`[1, 2]`))
+	assert.False(t, MatchGomega(BodyJSON, keyMatcher{"name"})(req))
+	assert.Equal(t, "rejected by the matcher function", mrClient.mismatch(&mrClient.mockData[2], req))
+}
@@ -0,0 +1,76 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Endpoints served by the responder itself in server mode, so that other
+// processes can confirm that the mock is up and loaded with the expected
+// responses before starting the system under test.  Requests to these paths
+// don't consume mocked responses and aren't recorded.
+const (
+	// HealthPath reports the status and the number of (unserved) responses.
+	HealthPath = "/__health"
+	// StubsPath lists the mocked responses.
+	StubsPath = "/__stubs"
+)
+
+// Health is the body of the HealthPath endpoint.
+type Health struct {
+	Status   string `json:"status"`
+	Stubs    int    `json:"stubs"`
+	Unserved int    `json:"unserved"`
+	Requests int    `json:"requests"`
+}
+
+// StubInfo describes a mocked response in the body of the StubsPath endpoint.
+type StubInfo struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name,omitempty"`
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Code   int    `json:"code"`
+	Served bool   `json:"served"`
+}
+
+// serveAdmin answers requests to the responder's own endpoints, it returns
+// false for all other requests.
+func (m *MockResponder) serveAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != HealthPath && r.URL.Path != StubsPath {
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return true
+	}
+
+	var v any
+	m.mu.Lock()
+	switch r.URL.Path {
+	case HealthPath:
+		h := Health{Status: "ok", Stubs: len(m.mockData), Requests: m.requests}
+		for _, d := range m.mockData {
+			if !d.served {
+				h.Unserved++
+			}
+		}
+		v = h
+	case StubsPath:
+		stubs := make([]StubInfo, 0, len(m.mockData))
+		for i, d := range m.mockData {
+			code := d.Code
+			if code == 0 {
+				code = http.StatusOK
+			}
+			stubs = append(stubs, StubInfo{Index: i, Name: d.Name, Method: d.Method, URL: d.URL, Code: code, Served: d.served})
+		}
+		v = stubs
+	}
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+	return true
+}
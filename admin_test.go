@@ -0,0 +1,46 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_serveAdmin(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Name: "list", Method: http.MethodGet, URL: "/devices$"},
+		MockResp{Name: "create", URL: "/devices$", Code: http.StatusCreated},
+	})
+	s := mrClient.Server()
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/devices")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	var h Health
+	resp, err = s.Client().Get(s.URL + HealthPath)
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&h))
+	resp.Body.Close()
+	assert.Equal(t, Health{Status: "ok", Stubs: 2, Unserved: 1, Requests: 1}, h)
+
+	var stubs []StubInfo
+	resp, err = s.Client().Get(s.URL + StubsPath)
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&stubs))
+	resp.Body.Close()
+	assert.Equal(t, []StubInfo{
+		{Index: 0, Name: "list", Method: http.MethodGet, URL: "/devices$", Code: http.StatusOK, Served: true},
+		{Index: 1, Name: "create", URL: "/devices$", Code: http.StatusCreated},
+	}, stubs)
+
+	resp, err = s.Client().Post(s.URL+StubsPath, "application/json", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Len(t, mrClient.History(), 1)
+}
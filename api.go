@@ -0,0 +1,266 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// API is a fake HTTP API defined in Go, e.g.
+//
+//	getStatus := func(req *http.Request, params map[string]string) MockResp {
+//		return JSONResp(status{Device: params["id"], Up: true})
+//	}
+//	api := NewAPI().
+//		Collection("/devices", device{ID: "r1"}, device{ID: "r2"}).
+//		Handle(http.MethodGet, "/devices/{id}/status", getStatus)
+//	mrClient.SetAPI(api)
+//
+// The routes are matched like mocked responses with a Method and a
+// PathTemplate, in the order they were added, but they are never consumed.  A
+// responder serves requests which match none of its unserved mocked responses
+// by the API, ahead of the fallback.  This allows to override single requests
+// of the API with mocked responses, e.g. to inject a failure.
+type API struct {
+	routes []apiRoute
+}
+
+// APIHandler returns the response to a request of an API route, params holds
+// the parameters of the path template of the route.
+type APIHandler func(req *http.Request, params map[string]string) MockResp
+
+// apiRoute is a route of an API, the matchers of the route are held by stub.
+type apiRoute struct {
+	stub    MockResp
+	handler APIHandler
+}
+
+// NewAPI returns an API without routes.
+func NewAPI() *API {
+	return &API{}
+}
+
+// Handle adds a route which serves requests with the method to paths matching
+// the template, e.g. "/devices/{id}", see MockResp.PathTemplate.  An empty
+// method matches any method.
+func (a *API) Handle(method, template string, handler APIHandler) *API {
+	a.routes = append(a.routes, apiRoute{
+		stub:    MockResp{Method: method, PathTemplate: template},
+		handler: handler,
+	})
+	return a
+}
+
+// Collection adds routes for an in-memory collection of JSON objects at path,
+// e.g. "/devices", with the given initial items:
+//
+//	GET path          lists the items as a JSON array
+//	POST path         adds the item from the request body, 201 Created, 409
+//	                  Conflict if there's an item with the same id
+//	GET path/{id}     returns the item, 404 Not Found if there's none
+//	PUT path/{id}     replaces the item, 404 Not Found if there's none
+//	DELETE path/{id}  deletes the item, 204 No Content
+//
+// Items are identified by their id field, an item without one is assigned a
+// generated identifier which isn't taken.  It panics if an initial item isn't
+// a JSON object or has the id of another item.
+func (a *API) Collection(path string, items ...any) *API {
+	path = strings.TrimSuffix(path, "/")
+	c := &collection{items: make(map[string]map[string]any)}
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		var obj map[string]any
+		if err == nil {
+			err = json.Unmarshal(data, &obj)
+		}
+		if err != nil || obj == nil {
+			panic(fmt.Sprintf("collection %s: item %d is not a JSON object", path, i))
+		}
+		if _, ok := c.add(obj); !ok {
+			panic(fmt.Sprintf("collection %s: item %d has a duplicate id", path, i))
+		}
+	}
+	item := path + "/{" + resourceIDField + "}"
+	return a.
+		Handle(http.MethodGet, path, c.list).
+		Handle(http.MethodPost, path, c.create(path)).
+		Handle(http.MethodGet, item, c.get).
+		Handle(http.MethodPut, item, c.replace).
+		Handle(http.MethodDelete, item, c.delete)
+}
+
+// collection is an in-memory collection of JSON objects of an API.  It has its
+// own lock as an API can be used by several responders.
+type collection struct {
+	mu     sync.Mutex
+	order  []string
+	items  map[string]map[string]any
+	lastID int
+}
+
+// add adds the object to the collection and returns its identifier, false if
+// the collection holds an item with the same identifier already.  Generated
+// identifiers skip the identifiers which are taken.
+func (c *collection) add(obj map[string]any) (string, bool) {
+	id := ""
+	if v, ok := obj[resourceIDField]; ok {
+		id = strings.Trim(jsonString(v), `"`)
+		if _, taken := c.items[id]; taken {
+			return id, false
+		}
+	} else {
+		for {
+			c.lastID++
+			id = strconv.Itoa(c.lastID)
+			if _, taken := c.items[id]; !taken {
+				break
+			}
+		}
+		obj[resourceIDField] = id
+	}
+	c.order = append(c.order, id)
+	c.items[id] = obj
+	return id, true
+}
+
+func (c *collection) list(req *http.Request, params map[string]string) MockResp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]map[string]any, 0, len(c.order))
+	for _, id := range c.order {
+		list = append(list, c.items[id])
+	}
+	return JSONResp(list)
+}
+
+func (c *collection) create(path string) APIHandler {
+	return func(req *http.Request, params map[string]string) MockResp {
+		obj, ok := decodeObject(req)
+		if !ok {
+			return ProblemResp(http.StatusBadRequest, "", "Bad Request", "the body is not a JSON object")
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		id, ok := c.add(obj)
+		if !ok {
+			return ProblemResp(http.StatusConflict, "", "Conflict", fmt.Sprintf("an item with id %q exists already", id))
+		}
+		resp := JSONResp(obj)
+		resp.Code = http.StatusCreated
+		resp.Header.Set("Location", path+"/"+id)
+		return resp
+	}
+}
+
+func (c *collection) get(req *http.Request, params map[string]string) MockResp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	obj, ok := c.items[params[resourceIDField]]
+	if !ok {
+		return ProblemResp(http.StatusNotFound, "", "Not Found", "")
+	}
+	return JSONResp(obj)
+}
+
+func (c *collection) replace(req *http.Request, params map[string]string) MockResp {
+	obj, ok := decodeObject(req)
+	if !ok {
+		return ProblemResp(http.StatusBadRequest, "", "Bad Request", "the body is not a JSON object")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := params[resourceIDField]
+	if _, ok := c.items[id]; !ok {
+		return ProblemResp(http.StatusNotFound, "", "Not Found", "")
+	}
+	// the identifier is taken from the path
+	obj[resourceIDField] = id
+	c.items[id] = obj
+	return JSONResp(obj)
+}
+
+func (c *collection) delete(req *http.Request, params map[string]string) MockResp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := params[resourceIDField]
+	if _, ok := c.items[id]; !ok {
+		return ProblemResp(http.StatusNotFound, "", "Not Found", "")
+	}
+	delete(c.items, id)
+	for i, v := range c.order {
+		if v == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	return NoContentResp()
+}
+
+// decodeObject returns the JSON object in the body of the request.
+func decodeObject(req *http.Request) (map[string]any, bool) {
+	var obj map[string]any
+	if err := json.Unmarshal(payload(req), &obj); err != nil || obj == nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+// SetAPI sets the API which serves the requests which match none of the
+// unserved mocked responses, ahead of the fallback, see API.  A nil API
+// removes the API.
+func (m *MockResponder) SetAPI(api *API) {
+	m.mu.Lock()
+	m.api = api
+	m.mu.Unlock()
+}
+
+// serveAPI serves the request by the API, false if no route of the API matches
+// the request.
+func (m *MockResponder) serveAPI(req *http.Request) (*http.Response, bool, error) {
+	resp, ok, err := m.apiResp(req)
+	if !ok {
+		return nil, false, nil
+	}
+	m.logf("api request %s %s", req.Method, sanitizeURL(req.URL.String()))
+	m.record(req, -1, "")
+	if err != nil {
+		return nil, true, err
+	}
+	r, err := m.respond(req, resp)
+	return r, true, err
+}
+
+// apiRoute returns the first route of the API which matches the request.
+func (m *MockResponder) apiRoute(req *http.Request) (*apiRoute, bool) {
+	if m.api == nil {
+		return nil, false
+	}
+	for i := range m.api.routes {
+		if m.mismatch(&m.api.routes[i].stub, req) == "" {
+			return &m.api.routes[i], true
+		}
+	}
+	return nil, false
+}
+
+// apiResp returns the response of the API to the request, false if no route of
+// the API matches the request.
+func (m *MockResponder) apiResp(req *http.Request) (MockResp, bool, error) {
+	route, ok := m.apiRoute(req)
+	if !ok {
+		return MockResp{}, false, nil
+	}
+	params := m.pathParams(&route.stub, req)
+	handler := route.handler
+	var resp MockResp
+	what := func() string {
+		return fmt.Sprintf("handler of API route %s %s", route.stub.Method, route.stub.PathTemplate)
+	}
+	if err := m.guard(what, func() { resp = handler(req, params) }); err != nil {
+		return MockResp{}, true, err
+	}
+	return resp, true, nil
+}
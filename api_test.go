@@ -0,0 +1,113 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetAPI(t *testing.T) {
+	type device struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	api := NewAPI().
+		Collection("/devices", device{ID: "r1", Name: "core"}).
+		Handle(http.MethodGet, "/devices/{id}/status", func(req *http.Request, params map[string]string) MockResp {
+			return JSONResp(map[string]string{"device": params["id"], "status": "up"})
+		})
+
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetAPI(api)
+	mrClient.SetData(MockRespList{
+		MockResp{Method: http.MethodGet, URL: "/devices/r1/status$", Code: http.StatusServiceUnavailable},
+	})
+
+	call := func(method, url, body string) (int, string) {
+		req, _ := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	// the mocked response overrides the API once
+	code, _ := call(http.MethodGet, "/devices/r1/status", "")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, mrClient.Empty())
+	code, body := call(http.MethodGet, "/devices/r1/status", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"device": "r1", "status": "up"}`, body)
+
+	code, body = call(http.MethodPost, "/devices", `{"name": "edge"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.JSONEq(t, `{"id": "1", "name": "edge"}`, body)
+	_, body = call(http.MethodGet, "/devices", "")
+	assert.JSONEq(t, `[{"id": "r1", "name": "core"}, {"id": "1", "name": "edge"}]`, body)
+
+	code, body = call(http.MethodPut, "/devices/1", `{"name": "edge-new"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"id": "1", "name": "edge-new"}`, body)
+	_, body = call(http.MethodGet, "/devices/1", "")
+	assert.JSONEq(t, `{"id": "1", "name": "edge-new"}`, body)
+
+	code, _ = call(http.MethodDelete, "/devices/r1", "")
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = call(http.MethodGet, "/devices/r1", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = call(http.MethodPut, "/devices/r1", `{}`)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = call(http.MethodPost, "/devices", `[]`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	_, body = call(http.MethodGet, "/devices", "")
+	assert.JSONEq(t, `[{"id": "1", "name": "edge-new"}]`, body)
+
+	// API requests are recorded but not unexpected
+	assert.Len(t, mrClient.History(), 11)
+	assert.Empty(t, mrClient.Unexpected())
+
+	assert.Panics(t, func() { NewAPI().Collection("/bad", 42) })
+}
+
+func TestAPI_Collection_IDs(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetAPI(NewAPI().Collection("/d", item{ID: 1, Name: "one"}, item{ID: 3, Name: "three"}))
+
+	post := func(body string) (int, string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/d", strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	// generated identifiers skip the seeded ones
+	code, body := post(`{"name": "two"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.JSONEq(t, `{"id": "2", "name": "two"}`, body)
+	code, body = post(`{"name": "four"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.JSONEq(t, `{"id": "4", "name": "four"}`, body)
+
+	// an explicit duplicate is rejected
+	code, _ = post(`{"id": 1, "name": "other"}`)
+	assert.Equal(t, http.StatusConflict, code)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/d/1", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.JSONEq(t, `{"id": 1, "name": "one"}`, string(data))
+
+	assert.Panics(t, func() { NewAPI().Collection("/d", item{ID: 1}, item{ID: 1}) })
+}
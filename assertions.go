@@ -0,0 +1,167 @@
+package mockresponder
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// TestingT is the interface of testing.T which is used by the assertion
+// helpers.
+type TestingT interface {
+	Errorf(format string, args ...any)
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// AssertNoUnexpected reports an error to t for every request which did not
+// match any of the mocked responses and was answered by the fallback response.
+// It returns true if there were no such requests.
+func (m *MockResponder) AssertNoUnexpected(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	unexpected := m.Unexpected()
+	for _, r := range unexpected {
+		t.Errorf("unexpected request %s %s", r.Method, sanitizeURL(r.URL))
+	}
+	return len(unexpected) == 0
+}
+
+// AssertHappensBefore reports an error to t unless the response named before
+// was served before the response named after.  As requests are served one at a
+// time, this establishes a happens-before relationship between requests issued
+// by concurrent workers.
+func (m *MockResponder) AssertHappensBefore(t TestingT, before, after string) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	first := func(name string) *Interaction {
+		for _, i := range m.History() {
+			if i.Name == name {
+				return &i
+			}
+		}
+		return nil
+	}
+	b, a := first(before), first(after)
+	switch {
+	case b == nil:
+		t.Errorf("%q was not served", before)
+	case a == nil:
+		t.Errorf("%q was not served", after)
+	case b.Seq > a.Seq:
+		t.Errorf("%q (worker %q, #%d) was served after %q (worker %q, #%d)",
+			before, b.Worker, b.Seq, after, a.Worker, a.Seq)
+	default:
+		return true
+	}
+	return false
+}
+
+// AssertNotServed reports an error to t if the response with the given name
+// has been served.  If there's no response with that name, it is used as a
+// regular expression instead and an error is reported for every served request
+// whose URL matches.  This allows to verify negative expectations, e.g. that a
+// client in read-only mode never calls a delete endpoint.
+func (m *MockResponder) AssertNotServed(t TestingT, nameOrPattern string) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	m.mu.Lock()
+	named, served := false, false
+	for _, d := range m.mockData {
+		if d.Name == nameOrPattern {
+			named = true
+			served = served || d.served
+		}
+	}
+	m.mu.Unlock()
+	if named {
+		if served {
+			t.Errorf("%q was served", nameOrPattern)
+		}
+		return !served
+	}
+
+	re, err := regexp.Compile(nameOrPattern)
+	if err != nil {
+		t.Errorf("%q is neither a response name nor a valid pattern: %s", nameOrPattern, err)
+		return false
+	}
+	ok := true
+	for _, i := range m.History() {
+		if re.MatchString(i.Request.URL) {
+			t.Errorf("request %s %s matching %q was served", i.Request.Method, sanitizeURL(i.Request.URL), nameOrPattern)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertNoViolations reports an error to t for every violation recorded by the
+// responder, see Violations().  It returns true if there were none.
+func (m *MockResponder) AssertNoViolations(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	violations := m.Violations()
+	for _, v := range violations {
+		t.Errorf("%s", v)
+	}
+	return len(violations) == 0
+}
+
+// AssertEmptyForTag reports an error to t for every response with the given
+// tag which has not been served, see EmptyForTag.
+func (m *MockResponder) AssertEmptyForTag(t TestingT, tag string) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	m.mu.Lock()
+	var unserved []string
+	for i, d := range m.mockData {
+		if d.Tag == tag && !d.served {
+			unserved = append(unserved, fmt.Sprintf("%d (%q)", i, d.Name))
+		}
+	}
+	m.mu.Unlock()
+	for _, u := range unserved {
+		t.Errorf("response %s tagged %q was not served", u, tag)
+	}
+	return len(unserved) == 0
+}
+
+// AssertBodiesClosed reports an error to t for every body of a served response
+// which has not been read completely or not been closed by the client.  Real
+// transports only reuse a connection once its response body was drained and
+// closed, leaking bodies exhausts the connection pool.  It returns true if all
+// bodies were read and closed.  Closed bodies are no longer retained, only the
+// requests of those which were not read completely are, the last ones up to
+// the history limit if it's set, see SetHistoryLimit.
+func (m *MockResponder) AssertBodiesClosed(t TestingT) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+	m.mu.Lock()
+	m.pruneBodies()
+	undrained := append([]string(nil), m.undrained...)
+	bodies := append([]*mockBody(nil), m.bodies...)
+	m.mu.Unlock()
+	ok := len(undrained) == 0
+	for _, request := range undrained {
+		t.Errorf("response body of %s was not read completely", request)
+	}
+	for _, b := range bodies {
+		if !b.isDrained() {
+			t.Errorf("response body of %s was not read completely", b.request)
+			ok = false
+		}
+		if !b.isClosed() {
+			t.Errorf("response body of %s was not closed", b.request)
+			ok = false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,170 @@
+package mockresponder
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockT struct {
+	errors []string
+}
+
+func (t *mockT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockResponder_AssertNoUnexpected(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "ok$"},
+	})
+	mrClient.SetFallback(MockResp{Code: http.StatusNotFound})
+
+	mt := &mockT{}
+	assert.True(t, mrClient.AssertNoUnexpected(mt))
+
+	for _, url := range []string{"bla://bla/ok", "bla://bla/nope", "bla://bla/ok"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	unexpected := mrClient.Unexpected()
+	assert.Len(t, unexpected, 2)
+	assert.Equal(t, "bla://bla/nope", unexpected[0].URL)
+	assert.False(t, mrClient.AssertNoUnexpected(mt))
+	assert.Equal(t, []string{
+		"unexpected request GET bla://bla/nope",
+		"unexpected request GET bla://bla/ok",
+	}, mt.errors)
+}
+
+func TestMockResponder_AssertNotServed(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Name: "list", URL: "/devices$"},
+		MockResp{Name: "delete", URL: "/devices/1$"},
+	})
+	mrClient.SetFallback(MockResp{Code: http.StatusNotFound})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/devices", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.True(t, mrClient.AssertNotServed(t, "delete"))
+	assert.True(t, mrClient.AssertNotServed(t, "/devices/[0-9]+$"))
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodDelete, "http://bla/devices/2", nil)
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	mt := &mockT{}
+	assert.False(t, mrClient.AssertNotServed(mt, "list"))
+	assert.False(t, mrClient.AssertNotServed(mt, "/devices/[0-9]+$"))
+	assert.False(t, mrClient.AssertNotServed(mt, "("))
+	assert.Equal(t, `"list" was served`, mt.errors[0])
+	assert.Equal(t, `request DELETE http://bla/devices/2 matching "/devices/[0-9]+$" was served`, mt.errors[1])
+	assert.Len(t, mt.errors, 3)
+}
+
+func TestMockResponder_AssertEmptyForTag(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Name: "invoice", Tag: "billing", URL: "/invoices$"},
+		MockResp{Name: "charge", Tag: "billing", URL: "/charges$"},
+		MockResp{Name: "device", Tag: "inventory", URL: "/devices$"},
+	})
+
+	mt := &mockT{}
+	assert.False(t, mrClient.EmptyForTag("billing"))
+	assert.False(t, mrClient.AssertEmptyForTag(mt, "billing"))
+	assert.Equal(t, []string{
+		`response 0 ("invoice") tagged "billing" was not served`,
+		`response 1 ("charge") tagged "billing" was not served`,
+	}, mt.errors)
+
+	for _, url := range []string{"http://bla/charges", "http://bla/invoices"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.True(t, mrClient.EmptyForTag("billing"))
+	assert.True(t, mrClient.AssertEmptyForTag(t, "billing"))
+	assert.False(t, mrClient.EmptyForTag("inventory"))
+	assert.True(t, mrClient.EmptyForTag("unknown"))
+}
+
+func TestMockResponder_AssertBodiesClosed(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/read$", Data: []byte(`read`)},
+		MockResp{URL: "/unread$", Data: []byte(`unread`)},
+		MockResp{URL: "/leaked$", Data: []byte(`leaked`)},
+		MockResp{URL: "/empty$", Code: http.StatusNoContent},
+	})
+
+	get := func(url string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+	resp := get("http://bla/read")
+	_, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.True(t, mrClient.AssertBodiesClosed(t))
+
+	get("http://bla/unread").Body.Close()
+	leaked := get("http://bla/leaked")
+	get("http://bla/empty")
+
+	mt := &mockT{}
+	assert.False(t, mrClient.AssertBodiesClosed(mt))
+	assert.Equal(t, []string{
+		"response body of GET http://bla/unread was not read completely",
+		"response body of GET http://bla/leaked was not read completely",
+		"response body of GET http://bla/leaked was not closed",
+	}, mt.errors)
+
+	_, _ = io.ReadAll(leaked.Body)
+	leaked.Body.Close()
+	mrClient.ResetAll()
+	assert.True(t, mrClient.AssertBodiesClosed(t))
+}
+
+func TestMockResponder_AssertBodiesClosedPruned(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetHistoryLimit(2)
+	var data MockRespList
+	for i := 0; i < 10; i++ {
+		data = append(data, MockResp{Data: []byte(`body`)})
+	}
+	mrClient.SetData(data)
+
+	for i := 0; i < 10; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://bla/%d", i), nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		if i%2 == 0 {
+			_, _ = io.ReadAll(resp.Body)
+		}
+		resp.Body.Close()
+	}
+
+	// closed bodies are dropped, only the last undrained ones are reported
+	mt := &mockT{}
+	assert.False(t, mrClient.AssertBodiesClosed(mt))
+	assert.Empty(t, mrClient.bodies)
+	assert.Equal(t, []string{
+		"response body of GET http://bla/7 was not read completely",
+		"response body of GET http://bla/9 was not read completely",
+	}, mt.errors)
+}
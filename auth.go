@@ -0,0 +1,40 @@
+package mockresponder
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Credentials are the username and password of HTTP basic authentication.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// mismatchBasicAuth checks that the request carries the wanted basic
+// authentication credentials.
+func mismatchBasicAuth(want *Credentials, req *http.Request) string {
+	username, password, ok := req.BasicAuth()
+	switch {
+	case !ok:
+		return "basic authentication is missing"
+	case username != want.Username:
+		return fmt.Sprintf("basic authentication user %q is not %q", username, want.Username)
+	case password != want.Password:
+		return fmt.Sprintf("basic authentication password of %q is wrong", username)
+	}
+	return ""
+}
+
+// mismatchBearerToken checks that the request carries the wanted bearer token.
+func mismatchBearerToken(want string, req *http.Request) string {
+	scheme, token, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "bearer token is missing"
+	}
+	if token = strings.TrimSpace(token); token != want {
+		return "bearer token is wrong"
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package mockresponder
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_MatchBasicAuth(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{MatchBasicAuth: &Credentials{Username: "admin", Password: "secret"}},
+		MockResp{Code: http.StatusUnauthorized},
+	})
+
+	get := func(username, password string) int {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/devices", nil)
+		req.SetBasicAuth(username, password)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, get("admin", "wrong"))
+	assert.Equal(t, http.StatusOK, get("admin", "secret"))
+
+	want := &MockResp{MatchBasicAuth: &Credentials{Username: "admin", Password: "secret"}}
+	req, _ := http.NewRequest(http.MethodGet, "/devices", nil)
+	assert.Equal(t, "basic authentication is missing", mrClient.mismatch(want, req))
+	req.SetBasicAuth("guest", "secret")
+	assert.Equal(t, `basic authentication user "guest" is not "admin"`, mrClient.mismatch(want, req))
+	req.SetBasicAuth("admin", "wrong")
+	assert.Equal(t, `basic authentication password of "admin" is wrong`, mrClient.mismatch(want, req))
+}
+
+func TestMockResponder_MatchBearerToken(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/devices$", MatchBearerToken: "fresh", Data: []byte(`[]`)},
+		MockResp{URL: "/devices$", MatchBearerToken: "stale", Code: http.StatusUnauthorized},
+	})
+
+	get := func(token string) int {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/devices", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, get("stale"))
+	assert.Equal(t, http.StatusOK, get("fresh"))
+
+	want := &MockResp{MatchBearerToken: "fresh"}
+	req, _ := http.NewRequest(http.MethodGet, "/devices", nil)
+	assert.Equal(t, "bearer token is missing", mrClient.mismatch(want, req))
+	req.SetBasicAuth("admin", "secret")
+	assert.Equal(t, "bearer token is missing", mrClient.mismatch(want, req))
+	req.Header.Set("Authorization", "bearer stale")
+	assert.Equal(t, "bearer token is wrong", mrClient.mismatch(want, req))
+}
@@ -0,0 +1,121 @@
+package mockresponder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+var errBodyClosed = errors.New("read on closed response body")
+
+// mockBody is the body of a mocked response.  Once the data has been read, it
+// either returns io.EOF, the configured error or it blocks until the body is
+// closed or the request context is done.
+type mockBody struct {
+	r         io.Reader
+	err       error
+	hang      bool
+	ctx       context.Context
+	closed    chan struct{}
+	closeOnce sync.Once
+	drained   int32
+	request   string
+}
+
+func newMockBody(ctx context.Context, r io.Reader, data MockResp) *mockBody {
+	return &mockBody{
+		r:      r,
+		err:    data.BodyErr,
+		hang:   data.BodyHang,
+		ctx:    ctx,
+		closed: make(chan struct{}),
+	}
+}
+
+func (b *mockBody) Read(p []byte) (int, error) {
+	select {
+	case <-b.closed:
+		return 0, errBodyClosed
+	default:
+	}
+	n, err := b.r.Read(p)
+	if n > 0 && err == io.EOF {
+		// the next read returns io.EOF again and applies the body faults
+		return n, nil
+	}
+	if err != io.EOF {
+		return n, err
+	}
+	atomic.StoreInt32(&b.drained, 1)
+	switch {
+	case b.err != nil:
+		return 0, b.err
+	case b.hang:
+		select {
+		case <-b.closed:
+			return 0, errBodyClosed
+		case <-b.ctx.Done():
+			return 0, b.ctx.Err()
+		}
+	}
+	return 0, io.EOF
+}
+
+func (b *mockBody) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.closed)
+		if c, ok := b.r.(io.Closer); ok {
+			err = c.Close()
+		}
+	})
+	return err
+}
+
+// isClosed returns true if the body has been closed.
+func (b *mockBody) isClosed() bool {
+	select {
+	case <-b.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// isDrained returns true if the data of the body has been read completely.
+func (b *mockBody) isDrained() bool {
+	return atomic.LoadInt32(&b.drained) == 1
+}
+
+// trackBody keeps track of the body of the response to the request, see
+// AssertBodiesClosed.
+func (m *MockResponder) trackBody(req *http.Request, b *mockBody) {
+	b.request = req.Method + " " + sanitizeURL(req.URL.String())
+	m.pruneBodies()
+	m.bodies = append(m.bodies, b)
+}
+
+// pruneBodies drops the tracked bodies which have been closed.  Of a closed
+// body which was not read completely only its request is kept, the last ones
+// up to the history limit if it's set, see SetHistoryLimit.
+func (m *MockResponder) pruneBodies() {
+	open := m.bodies[:0]
+	for _, b := range m.bodies {
+		switch {
+		case !b.isClosed():
+			open = append(open, b)
+		case !b.isDrained():
+			m.undrained = append(m.undrained, b.request)
+		}
+	}
+	for i := len(open); i < len(m.bodies); i++ {
+		m.bodies[i] = nil
+	}
+	m.bodies = open
+	if excess := len(m.undrained) - m.historyLimit; m.historyLimit > 0 && excess > 0 {
+		m.undrained = append([]string(nil), m.undrained[excess:]...)
+	}
+}
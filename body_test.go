@@ -0,0 +1,145 @@
+package mockresponder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_BodyFaults(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	bodyErr := errors.New("connection reset")
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`partial`), BodyErr: bodyErr},
+		MockResp{Data: []byte(`hang`), BodyHang: true},
+		MockResp{Data: []byte(`closed`), BodyHang: true},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, bodyErr)
+	assert.Equal(t, []byte(`partial`), body)
+	resp.Body.Close()
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	req, _ = http.NewRequestWithContext(cancelCtx, http.MethodGet, "/bla", nil)
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	cancel()
+	body, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []byte(`hang`), body)
+	resp.Body.Close()
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	go resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, errBodyClosed)
+}
+
+func TestMockResp_BodyReader(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	pr, pw := io.Pipe()
+	mrClient.SetData(MockRespList{
+		MockResp{BodyReader: pr, Header: http.Header{"Content-Type": []string{"text/plain"}}},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/stream", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), resp.ContentLength)
+	assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+
+	// the body is produced while the client reads it
+	go func() {
+		pw.Write([]byte(`chunk 1,`))
+		pw.Write([]byte(`chunk 2`))
+		pw.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`chunk 1,chunk 2`), body)
+
+	// closing the body closes the reader
+	pr, pw = io.Pipe()
+	mrClient.SetData(MockRespList{MockResp{BodyReader: pr}})
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	_, err = pw.Write([]byte(`late`))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+
+	assert.EqualError(t, MockResp{BodyReader: pr, Data: []byte(`both`)}.Validate(),
+		"body reader (BodyReader) is ambiguous with response fields Data")
+}
+
+func TestMockResp_BodyReaderDataEOF(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	bodyErr := errors.New("connection reset")
+	mrClient.SetData(MockRespList{
+		MockResp{BodyReader: iotest.DataErrReader(strings.NewReader(`partial`)), BodyErr: bodyErr},
+		MockResp{BodyReader: iotest.DataErrReader(strings.NewReader(`complete`))},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, bodyErr)
+	assert.Equal(t, []byte(`partial`), body)
+	resp.Body.Close()
+
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`complete`), body)
+	resp.Body.Close()
+	assert.True(t, mrClient.AssertBodiesClosed(t))
+}
+
+func TestMockResp_DataFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"devices": []}`), 0o600))
+	var logged []string
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	// a missing data file isn't a pattern error, the other responses are served
+	assert.NoError(t, mrClient.SetDataE(MockRespList{
+		MockResp{URL: "/missing$", DataFile: path + ".gone"},
+		MockResp{URL: "/dump$", DataFile: path},
+	}))
+	assert.Contains(t, strings.Join(logged, "\n"), `warning: response 0 ("/missing$"): missing data file`)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/dump", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, []byte(`{"devices": []}`), body)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "/missing", nil)
+	_, err = mrClient.Do(req)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	data, err := ParseFixtures(strings.NewReader(`[{"dataFile": "`+path+`"}]`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, path, data[0].DataFile)
+}
@@ -0,0 +1,52 @@
+package mockresponder
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SetRequestBudget sets the maximum time the client may take between
+// consecutive mocked requests, measured on the responder's clock.  Every
+// request which arrives later than that is recorded as a violation which
+// catches accidental sleeps or lock contention in the code under test, see
+// Violations().  A zero budget disables the check.
+func (m *MockResponder) SetRequestBudget(budget time.Duration) {
+	m.mu.Lock()
+	m.budget = budget
+	m.lastRequest = time.Time{}
+	m.mu.Unlock()
+}
+
+// checkBudget records a violation if the request arrived later than the
+// request budget allows.
+func (m *MockResponder) checkBudget(req *http.Request) {
+	now := m.now()
+	if m.budget > 0 && !m.lastRequest.IsZero() {
+		if gap := now.Sub(m.lastRequest); gap > m.budget {
+			m.violate("request %s %s arrived %s after the previous request, exceeding the budget of %s",
+				req.Method, sanitizeURL(req.URL.String()), gap, m.budget)
+		}
+	}
+	m.lastRequest = now
+}
+
+// violate records a violation of the expectations set on the responder.  In
+// a dry run, the violation is collected instead, see Match.
+func (m *MockResponder) violate(format string, args ...any) {
+	v := fmt.Sprintf(format, args...)
+	if m.dryRun != nil {
+		*m.dryRun = append(*m.dryRun, v)
+		return
+	}
+	m.logf("violation: %s", v)
+	m.violations = append(m.violations, v)
+}
+
+// Violations returns the violations of the expectations set on the responder,
+// like the request budget, in the order they occurred.
+func (m *MockResponder) Violations() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.violations...)
+}
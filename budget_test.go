@@ -0,0 +1,36 @@
+package mockresponder
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetRequestBudget(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetClock(func() time.Time { return now })
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}, MockResp{}})
+	mrClient.SetRequestBudget(time.Second)
+
+	get := func(after time.Duration) {
+		now = now.Add(after)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	get(time.Hour)
+	get(500 * time.Millisecond)
+	mt := &mockT{}
+	assert.True(t, mrClient.AssertNoViolations(mt))
+
+	get(3 * time.Second)
+	assert.False(t, mrClient.AssertNoViolations(mt))
+	assert.Equal(t, []string{"request GET /bla arrived 3s after the previous request, exceeding the budget of 1s"}, mt.errors)
+
+	mrClient.ResetAll()
+	assert.Empty(t, mrClient.Violations())
+}
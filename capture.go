@@ -0,0 +1,193 @@
+package mockresponder
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+const contextWorker = contextKey("worker")
+
+// WorkerHeader is the request header which identifies the worker issuing a
+// request if the request context has no worker, see WithWorker.  Clients set
+// it to the same value for all requests of a worker, unlike request IDs which
+// are unique per request.
+const WorkerHeader = "X-Mock-Worker"
+
+// CapturedRequest is a snapshot of a request received by the mock responder.
+// If the client compressed the body with gzip or deflate, Body holds the
+// decompressed payload.
+type CapturedRequest struct {
+	Method  string
+	URL     string
+	Header  http.Header
+	Body    []byte
+	Trailer http.Header
+	// Raw holds the request as received on the wire if raw capture is
+	// enabled, see SetRawCapture.
+	Raw []byte
+}
+
+// Interaction is a request served by the mock responder.
+type Interaction struct {
+	// Seq is the position of the interaction in the order of all served
+	// requests.
+	Seq int
+	// Worker identifies the goroutine or worker which issued the request.
+	Worker string
+	// Stub is the index of the response which served the request, -1 if the
+	// request wasn't served from the list, e.g. by the fallback response or
+	// during an outage.
+	Stub int
+	// Name is the name of the response which served the request.
+	Name    string
+	Request CapturedRequest
+}
+
+// WithWorker returns a context which identifies the worker issuing requests
+// with this context.  This allows to trace the order of requests per worker
+// when clients issue requests concurrently.
+func WithWorker(ctx context.Context, worker string) context.Context {
+	return context.WithValue(ctx, contextWorker, worker)
+}
+
+// workerOf returns the worker which issued the request.
+func workerOf(req *http.Request) string {
+	if w, ok := req.Context().Value(contextWorker).(string); ok {
+		return w
+	}
+	return req.Header.Get(WorkerHeader)
+}
+
+// HistoryStore persists the interaction history of a responder, e.g. on disk
+// or in a database so that long-running harnesses don't grow memory
+// unboundedly.  Append is called for every interaction in the order in which
+// they were served, List returns all stored interactions in that order.  The
+// responder serializes the calls.
+type HistoryStore interface {
+	Append(i Interaction) error
+	List() ([]Interaction, error)
+}
+
+// SetHistoryStore sets the store of the interaction history, by default the
+// history is kept in memory.  A nil store restores the in-memory history.
+// Errors of the store are logged.  ResetAll doesn't clear a custom store.
+func (m *MockResponder) SetHistoryStore(store HistoryStore) {
+	m.mu.Lock()
+	m.store = store
+	m.mu.Unlock()
+}
+
+// record adds the request to the interaction history and returns the captured
+// request.
+func (m *MockResponder) record(req *http.Request, stub int, name string) CapturedRequest {
+	cr := captureRequest(req)
+	i := Interaction{
+		Seq:     m.seq,
+		Worker:  workerOf(req),
+		Stub:    stub,
+		Name:    name,
+		Request: cr,
+	}
+	m.seq++
+	if m.store != nil {
+		if err := m.store.Append(i); err != nil {
+			m.logf("warning: history store: %s", err)
+		}
+		return cr
+	}
+	m.history = append(m.history, i)
+	m.trimHistory()
+	return cr
+}
+
+// SetHistoryLimit sets the maximum number of interactions kept in the
+// in-memory history, the oldest interactions are dropped first so that soak
+// tests don't exhaust memory.  A limit of 0, the default, keeps all
+// interactions.
+func (m *MockResponder) SetHistoryLimit(limit int) {
+	m.mu.Lock()
+	m.historyLimit = limit
+	m.trimHistory()
+	m.mu.Unlock()
+}
+
+// trimHistory drops the oldest interactions beyond the history limit.
+func (m *MockResponder) trimHistory() {
+	excess := len(m.history) - m.historyLimit
+	if m.historyLimit <= 0 || excess <= 0 {
+		return
+	}
+	n := copy(m.history, m.history[excess:])
+	// release the dropped interactions
+	for i := n; i < len(m.history); i++ {
+		m.history[i] = Interaction{}
+	}
+	m.history = m.history[:n]
+	m.dropped += excess
+}
+
+// DroppedInteractions returns the number of interactions which were dropped
+// from the history because of the history limit.
+func (m *MockResponder) DroppedInteractions() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dropped
+}
+
+// interactions returns the interaction history from the store.
+func (m *MockResponder) interactions() []Interaction {
+	if m.store == nil {
+		return append([]Interaction(nil), m.history...)
+	}
+	history, err := m.store.List()
+	if err != nil {
+		m.logf("warning: history store: %s", err)
+	}
+	return history
+}
+
+// History returns all interactions in the order in which they were served.
+func (m *MockResponder) History() []Interaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.interactions()
+}
+
+// Trace returns the interactions of the given worker in the order in which
+// they were served.
+func (m *MockResponder) Trace(worker string) []Interaction {
+	var trace []Interaction
+	for _, i := range m.History() {
+		if i.Worker == worker {
+			trace = append(trace, i)
+		}
+	}
+	return trace
+}
+
+// readBody reads the body of the request and replaces it with a reader on the
+// read data so that it can be consumed again.
+func readBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, _ := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// captureRequest takes a snapshot of the request.
+func captureRequest(req *http.Request) CapturedRequest {
+	body := payload(req)
+	return CapturedRequest{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Header:  req.Header.Clone(),
+		Body:    body,
+		Trailer: req.Trailer.Clone(),
+		Raw:     rawRequest(req),
+	}
+}
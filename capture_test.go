@@ -0,0 +1,161 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_Trace(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Name: "lock", URL: "/lock$"},
+		MockResp{Name: "write", URL: "/write$"},
+		MockResp{Name: "read", URL: "/read$"},
+		MockResp{Name: "unlock", URL: "/unlock$"},
+	})
+
+	get := func(worker, path string) {
+		req, _ := http.NewRequestWithContext(WithWorker(ctx, worker), http.MethodGet, path, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	get("w1", "/lock")
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		get("w1", "/write")
+	}()
+	go func() {
+		defer wg.Done()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/read", nil)
+		req.Header.Set(WorkerHeader, "w2")
+		// request IDs don't identify the worker
+		req.Header.Set("X-Request-Id", "5f1c")
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}()
+	wg.Wait()
+	get("w1", "/unlock")
+
+	assert.Len(t, mrClient.History(), 4)
+	trace := mrClient.Trace("w1")
+	assert.Len(t, trace, 3)
+	assert.Equal(t, "lock", trace[0].Name)
+	assert.Equal(t, "unlock", trace[2].Name)
+	assert.Equal(t, 3, trace[2].Seq)
+	assert.Len(t, mrClient.Trace("w2"), 1)
+
+	assert.True(t, mrClient.AssertHappensBefore(t, "lock", "read"))
+	assert.True(t, mrClient.AssertHappensBefore(t, "write", "unlock"))
+
+	mt := &mockT{}
+	assert.False(t, mrClient.AssertHappensBefore(mt, "unlock", "lock"))
+	assert.False(t, mrClient.AssertHappensBefore(mt, "lock", "nope"))
+	assert.Len(t, mt.errors, 2)
+	assert.Equal(t, `"nope" was not served`, mt.errors[1])
+}
+
+// fileStore is a history store which spills the interactions to a file.
+type fileStore struct {
+	path string
+	fail bool
+}
+
+func (s *fileStore) Append(i Interaction) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(i)
+}
+
+func (s *fileStore) List() ([]Interaction, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var history []Interaction
+	d := json.NewDecoder(f)
+	for d.More() {
+		var i Interaction
+		if err := d.Decode(&i); err != nil {
+			return history, err
+		}
+		history = append(history, i)
+	}
+	return history, nil
+}
+
+func TestMockResponder_SetHistoryStore(t *testing.T) {
+	var lines []string
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	mrClient.SetData(MockRespList{MockResp{Name: "first"}, MockResp{Name: "second"}, MockResp{}})
+	store := &fileStore{path: filepath.Join(t.TempDir(), "history.json")}
+	mrClient.SetHistoryStore(store)
+
+	get := func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	get()
+	get()
+	history := mrClient.History()
+	assert.Len(t, history, 2)
+	assert.Equal(t, 1, history[1].Seq)
+	assert.Equal(t, "second", history[1].Name)
+	req, ok := mrClient.LastRequest()
+	assert.True(t, ok)
+	assert.Equal(t, "/bla", req.URL)
+
+	store.fail = true
+	get()
+	assert.Contains(t, lines, "warning: history store: disk full")
+	assert.Len(t, mrClient.History(), 2)
+}
+
+func TestMockResponder_SetHistoryLimit(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetFallback(MockResp{})
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("/%d", i), nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		if i == 1 {
+			mrClient.SetHistoryLimit(3)
+		}
+	}
+	history := mrClient.History()
+	assert.Len(t, history, 3)
+	assert.Equal(t, "/2", history[0].Request.URL)
+	assert.Equal(t, 2, history[0].Seq)
+	assert.Equal(t, 2, mrClient.DroppedInteractions())
+
+	mrClient.SetHistoryLimit(1)
+	assert.Equal(t, "/4", mrClient.History()[0].Request.URL)
+	assert.Equal(t, 4, mrClient.DroppedInteractions())
+	mrClient.ResetAll()
+	assert.Zero(t, mrClient.DroppedInteractions())
+}
@@ -0,0 +1,53 @@
+package mockresponder
+
+import "net/http"
+
+// ResponderChain combines several mock responders into one.  This allows to
+// develop independent sets of mocked responses (e.g. for authentication and
+// for billing) separately and to combine them as needed in a test.
+type ResponderChain struct {
+	responders []*MockResponder
+}
+
+// Chain returns a responder chain which tries the given responders in order.
+func Chain(responders ...*MockResponder) *ResponderChain {
+	return &ResponderChain{responders: responders}
+}
+
+// Do satisfies the http.Client.Do() interface.  The request is served by the
+// first responder in the chain which has an unserved response, a stored
+// resource or an API route matching the request, like by the Do() of that
+// responder, including its latency, checks and failure injection.  The
+// fallbacks of the responders are not used.  If no responder serves the
+// request, the chain fails with ErrOutOfData according to the failure policy
+// of the first responder.
+//
+// A responder is asked whether it serves the request before it serves it, so
+// the Matcher functions and body comparators of its responses are called
+// twice for a chained request and must not have side effects.
+func (c *ResponderChain) Do(req *http.Request) (*http.Response, error) {
+	for _, r := range c.responders {
+		resp, ok, err := r.dispatch(req, true)
+		if ok {
+			return resp, err
+		}
+	}
+	if len(c.responders) == 0 {
+		panic(ErrOutOfData.Error())
+	}
+	r := c.responders[0]
+	r.logf("no responder in chain for %s %s", req.Method, sanitizeURL(req.URL.String()))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fail(ErrOutOfData)
+}
+
+// Empty returns true if all responders in the chain are empty.
+func (c *ResponderChain) Empty() bool {
+	for _, r := range c.responders {
+		if !r.Empty() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package mockresponder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_Do(t *testing.T) {
+	auth, _ := NewMockResponder()
+	auth.SetData(MockRespList{
+		MockResp{Data: []byte(`token`), URL: "/auth$"},
+	})
+	billing, _ := NewMockResponder()
+	billing.SetData(MockRespList{
+		MockResp{Data: []byte(`invoice`), URL: "/invoice$"},
+	})
+	custom, _ := NewMockResponder()
+	custom.SetDoFunc(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, custom, responderFromContext(req))
+		return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+	})
+
+	chain := Chain(auth, billing)
+	assert.False(t, chain.Empty())
+
+	for _, tt := range []struct {
+		url  string
+		want string
+	}{
+		{"bla://bla/invoice", "invoice"},
+		{"bla://bla/auth", "token"},
+	} {
+		req, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, tt.url, nil)
+		resp, err := chain.Do(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, tt.want, string(body))
+	}
+	assert.True(t, chain.Empty())
+
+	req, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, "bla://bla/auth", nil)
+	assert.Panics(t, func() { chain.Do(req) })
+	auth.SetFailurePolicy(FailError)
+	_, err := chain.Do(req)
+	assert.ErrorIs(t, err, ErrOutOfData)
+
+	chain = Chain(auth, custom)
+	resp, err := chain.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+}
+
+func TestChain_Do_Pipeline(t *testing.T) {
+	auth, _ := NewMockResponder()
+	auth.SetData(MockRespList{
+		MockResp{URL: "/auth$", Delay: 50 * time.Millisecond},
+		MockResp{URL: "/logout$", Name: "logout", Final: true},
+		MockResp{URL: "/auth$"},
+	})
+	billing, _ := NewMockResponder()
+	billing.SetData(MockRespList{MockResp{URL: "/invoice$"}, MockResp{URL: "/invoice$"}})
+	chain := Chain(auth, billing)
+
+	get := func(path string) error {
+		req, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://bla"+path, nil)
+		resp, err := chain.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// the delay of the response applies
+	start := time.Now()
+	assert.NoError(t, get("/auth"))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// requests after the final response of a responder are violations, a
+	// declined request doesn't count
+	assert.NoError(t, get("/logout"))
+	assert.NoError(t, get("/invoice"))
+	assert.Empty(t, auth.Violations())
+	assert.Empty(t, billing.Violations())
+	assert.NoError(t, get("/auth"))
+	assert.Equal(t, []string{
+		"request GET http://bla/auth arrived after the final response logout/1 was served",
+	}, auth.Violations())
+
+	// resetting the responder fails requests in flight
+	billing.SetLatency(100 * time.Millisecond)
+	done := make(chan error)
+	go func() { done <- get("/invoice") }()
+	assert.Eventually(t, func() bool { return billing.TotalRequests() == 2 }, time.Second, time.Millisecond)
+	billing.SetData(MockRespList{MockResp{URL: "/invoice$"}})
+	assert.ErrorIs(t, <-done, ErrReset)
+}
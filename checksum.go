@@ -0,0 +1,65 @@
+package mockresponder
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"net/http"
+)
+
+// Checksum is the name of a checksum header which the responder can compute
+// for response bodies.
+type Checksum string
+
+// Supported checksum headers, all values are base64 encoded.
+const (
+	ChecksumMD5    Checksum = "Content-MD5"
+	ChecksumCRC32  Checksum = "X-Amz-Checksum-Crc32"
+	ChecksumCRC32C Checksum = "X-Amz-Checksum-Crc32c"
+	ChecksumSHA1   Checksum = "X-Amz-Checksum-Sha1"
+	ChecksumSHA256 Checksum = "X-Amz-Checksum-Sha256"
+)
+
+func (c Checksum) hash() hash.Hash {
+	switch c {
+	case ChecksumMD5:
+		return md5.New()
+	case ChecksumCRC32:
+		return crc32.NewIEEE()
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case ChecksumSHA1:
+		return sha1.New()
+	case ChecksumSHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+// SetChecksums enables the given checksum headers on responses.  They are
+// computed over the body as transmitted, i.e. after content encoding, unless
+// the mocked response already has the header.  Calling it without arguments
+// disables checksum headers.
+func (m *MockResponder) SetChecksums(checksums ...Checksum) {
+	m.mu.Lock()
+	m.checksums = checksums
+	m.mu.Unlock()
+}
+
+// addChecksums sets the enabled checksum headers for the body.
+func (m *MockResponder) addChecksums(header http.Header, body []byte) {
+	for _, c := range m.checksums {
+		if len(header.Get(string(c))) > 0 {
+			continue
+		}
+		h := c.hash()
+		if h == nil {
+			continue
+		}
+		h.Write(body)
+		header.Set(string(c), base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	}
+}
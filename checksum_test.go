@@ -0,0 +1,33 @@
+package mockresponder
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetChecksums(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetChecksums(ChecksumMD5, ChecksumCRC32, ChecksumCRC32C, ChecksumSHA1, ChecksumSHA256, Checksum("X-Unknown"))
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`hello world`)},
+		MockResp{Data: []byte(`hello world`), Header: http.Header{"Content-Md5": []string{"bogus"}}},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "XrY7u+Ae7tCTyyK7j1rNww==", resp.Header.Get("Content-MD5"))
+	assert.Equal(t, "DUoRhQ==", resp.Header.Get("X-Amz-Checksum-Crc32"))
+	assert.Equal(t, "yZRlqg==", resp.Header.Get("X-Amz-Checksum-Crc32c"))
+	assert.Equal(t, "Kq5sNclPz7QV2+lfQIuc6R7oRu0=", resp.Header.Get("X-Amz-Checksum-Sha1"))
+	assert.Equal(t, "uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=", resp.Header.Get("X-Amz-Checksum-Sha256"))
+	assert.Empty(t, resp.Header.Get("X-Unknown"))
+
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "bogus", resp.Header.Get("Content-MD5"))
+}
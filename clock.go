@@ -0,0 +1,46 @@
+package mockresponder
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SetClock sets the function which the responder uses to determine the
+// current time, e.g. for the Date header of responses.  A nil function
+// restores the default of time.Now.
+func (m *MockResponder) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	m.clock = now
+	m.mu.Unlock()
+}
+
+// SetCacheHeaders enables the Expires and Age headers on responses.  Expires is
+// set to the current time plus expires, Age to age in seconds.  A zero
+// duration disables the respective header.
+func (m *MockResponder) SetCacheHeaders(expires, age time.Duration) {
+	m.mu.Lock()
+	m.expires = expires
+	m.age = age
+	m.mu.Unlock()
+}
+
+func (m *MockResponder) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock()
+}
+
+// stampDate sets the date related headers of a response based on the clock of
+// the responder.
+func (m *MockResponder) stampDate(header http.Header) {
+	now := m.now().UTC()
+	header.Set("Date", now.Format(http.TimeFormat))
+	if m.expires > 0 {
+		header.Set("Expires", now.Add(m.expires).Format(http.TimeFormat))
+	}
+	if m.age > 0 {
+		header.Set("Age", strconv.Itoa(int(m.age/time.Second)))
+	}
+}
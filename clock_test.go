@@ -0,0 +1,31 @@
+package mockresponder
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetClock(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	now := time.Date(2022, 11, 8, 10, 30, 0, 0, time.FixedZone("CET", 3600))
+	mrClient.SetClock(func() time.Time { return now })
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "Tue, 08 Nov 2022 09:30:00 GMT", resp.Header.Get("Date"))
+	assert.Empty(t, resp.Header.Get("Expires"))
+	assert.Empty(t, resp.Header.Get("Age"))
+
+	mrClient.SetCacheHeaders(time.Hour, time.Minute)
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "Tue, 08 Nov 2022 10:30:00 GMT", resp.Header.Get("Expires"))
+	assert.Equal(t, "60", resp.Header.Get("Age"))
+}
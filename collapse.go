@@ -0,0 +1,50 @@
+package mockresponder
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// collapsed tracks the last consumed response for duplicate suppression.
+type collapsed struct {
+	key  string
+	idx  int
+	when time.Time
+}
+
+// SetCollapseWindow enables duplicate suppression for polling clients: a
+// request which is identical (method, URL and body) to the request which
+// consumed the last response and arrives within the window after it is served
+// the same response again without consuming another one.  Zero disables
+// duplicate suppression.
+func (m *MockResponder) SetCollapseWindow(window time.Duration) {
+	m.mu.Lock()
+	m.collapseWindow = window
+	m.collapsed = collapsed{}
+	m.mu.Unlock()
+}
+
+func requestKey(req *http.Request) string {
+	return fmt.Sprintf("%s %s %x", req.Method, req.URL, sha256.Sum256(readBody(req)))
+}
+
+// collapse returns the index of the response to serve again if the request is
+// a duplicate within the collapse window.
+func (m *MockResponder) collapse(req *http.Request) (int, bool) {
+	if m.collapseWindow <= 0 || len(m.collapsed.key) == 0 {
+		return 0, false
+	}
+	if requestKey(req) != m.collapsed.key || m.now().Sub(m.collapsed.when) > m.collapseWindow {
+		return 0, false
+	}
+	return m.collapsed.idx, m.collapsed.idx < len(m.mockData)
+}
+
+// consumed remembers the request which consumed the response at idx.
+func (m *MockResponder) consumed(req *http.Request, idx int) {
+	if m.collapseWindow > 0 {
+		m.collapsed = collapsed{key: requestKey(req), idx: idx, when: m.now()}
+	}
+}
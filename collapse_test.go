@@ -0,0 +1,80 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetCollapseWindow(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	now := time.Now()
+	mrClient.SetClock(func() time.Time { return now })
+	mrClient.SetCollapseWindow(time.Second)
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/status$", Data: []byte(`pending`)},
+		MockResp{URL: "/status$", Data: []byte(`done`)},
+		MockResp{URL: "/job$"},
+	})
+
+	poll := func(method, path, body string) string {
+		req, _ := http.NewRequestWithContext(ctx, method, path, strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		return string(mrClient.LastData())
+	}
+
+	assert.Equal(t, "pending", poll(http.MethodGet, "/status", ""))
+	now = now.Add(time.Millisecond * 500)
+	assert.Equal(t, "pending", poll(http.MethodGet, "/status", ""))
+	assert.Equal(t, "pending", poll(http.MethodGet, "/status", ""))
+	now = now.Add(time.Second)
+	assert.Equal(t, "done", poll(http.MethodGet, "/status", ""))
+	assert.False(t, mrClient.Empty())
+
+	// a different body is not a duplicate
+	poll(http.MethodPost, "/job", "a")
+	assert.Panics(t, func() { poll(http.MethodPost, "/job", "b") })
+	assert.Equal(t, 6, mrClient.TotalRequests())
+	assert.Len(t, mrClient.History(), 5)
+	assert.True(t, mrClient.Empty())
+}
+
+func TestMockResponder_SetCollapseWindowTemplate(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	now := time.Now()
+	mrClient.SetClock(func() time.Time { return now })
+	mrClient.SetCollapseWindow(time.Second)
+	mrClient.SetData(MockRespList{
+		MockResp{PathTemplate: "/devices/{id}", Data: []byte(`{"id":"{id}"}`)},
+		MockResp{URL: "/devices$", Method: http.MethodPost, Creates: "/devices/{id}"},
+	})
+
+	do := func(method, path, body string) (int, string) {
+		req, _ := http.NewRequestWithContext(ctx, method, path, strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	_, body := do(http.MethodGet, "/devices/7", "")
+	assert.Equal(t, `{"id":"7"}`, body)
+	_, body = do(http.MethodGet, "/devices/7", "")
+	assert.Equal(t, `{"id":"7"}`, body)
+
+	// a collapsed duplicate doesn't create another resource
+	code, _ := do(http.MethodPost, "/devices", `{"name":"a"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	do(http.MethodPost, "/devices", `{"name":"a"}`)
+	code, body = do(http.MethodGet, "/devices/1", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"id":"1","name":"a"}`, body)
+	assert.Panics(t, func() { do(http.MethodGet, "/devices/2", "") })
+}
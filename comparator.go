@@ -0,0 +1,115 @@
+package mockresponder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// BodyComparator compares the body of a request with the expected body of a
+// mocked response, see MatchBody.  It returns nil if the bodies are equal or
+// an error describing the difference.
+type BodyComparator interface {
+	Compare(got, want []byte) error
+}
+
+// BodyComparatorFunc is a function which satisfies the BodyComparator
+// interface.
+type BodyComparatorFunc func(got, want []byte) error
+
+// Compare returns f(got, want).
+func (f BodyComparatorFunc) Compare(got, want []byte) error {
+	return f(got, want)
+}
+
+// The names of the built-in body comparators.
+const (
+	// CompareExact requires the bodies to be byte-wise equal.
+	CompareExact = "exact"
+	// CompareJSON requires the bodies to be structurally equal JSON documents,
+	// ignoring key order and whitespace.
+	CompareJSON = "json"
+	// CompareXML requires the bodies to be equal canonical XML documents,
+	// ignoring attribute order, namespace prefixes, comments and whitespace
+	// around text.
+	CompareXML = "xml"
+)
+
+var (
+	comparatorsMu sync.RWMutex
+	comparators   = map[string]BodyComparator{
+		CompareExact: BodyComparatorFunc(compareExact),
+		CompareJSON:  BodyComparatorFunc(compareJSON),
+		CompareXML:   BodyComparatorFunc(compareXML),
+	}
+)
+
+// RegisterBodyComparator registers a body comparator under the given name for
+// all responders, e.g. a protobuf-aware comparison.  Registering a comparator
+// with the name of an existing one replaces it.
+func RegisterBodyComparator(name string, c BodyComparator) {
+	comparatorsMu.Lock()
+	comparators[name] = c
+	comparatorsMu.Unlock()
+}
+
+// bodyComparator returns the comparator of the response, by default
+// CompareExact.
+func bodyComparator(data *MockResp) (BodyComparator, error) {
+	name := data.BodyComparator
+	if len(name) == 0 {
+		name = CompareExact
+	}
+	comparatorsMu.RLock()
+	c, ok := comparators[name]
+	comparatorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown body comparator %q", name)
+	}
+	return c, nil
+}
+
+// mismatchBody compares the body of the request with MatchBody.
+func (m *MockResponder) mismatchBody(data *MockResp, req *http.Request) string {
+	c, err := bodyComparator(data)
+	if err != nil {
+		// the comparator was validated before
+		return err.Error()
+	}
+	var cerr error
+	got, want := payload(req), data.MatchBody
+	what := func() string { return "body comparator of " + m.describe(data) }
+	if err := m.guard(what, func() { cerr = c.Compare(got, want) }); err != nil {
+		return err.Error()
+	}
+	if cerr != nil {
+		return fmt.Sprintf("body differs: %s", cerr)
+	}
+	return ""
+}
+
+// compareExact reports the structural differences of JSON bodies, see
+// jsonDiff, and both bodies otherwise.
+func compareExact(got, want []byte) error {
+	if bytes.Equal(got, want) {
+		return nil
+	}
+	if diffs, ok := jsonDiff(got, want); ok && len(diffs) > 0 {
+		return errors.New(strings.Join(diffs, "; "))
+	}
+	return fmt.Errorf("got %q, want %q", got, want)
+}
+
+func compareJSON(got, want []byte) error {
+	diffs, ok := jsonDiff(got, want)
+	if !ok {
+		return errors.New("not JSON")
+	}
+	if len(diffs) > 0 {
+		return errors.New(strings.Join(diffs, "; "))
+	}
+	return nil
+}
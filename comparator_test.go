@@ -0,0 +1,52 @@
+package mockresponder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_MatchBody(t *testing.T) {
+	RegisterBodyComparator("trimmed", BodyComparatorFunc(func(got, want []byte) error {
+		if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+			return errors.New("trimmed bodies differ")
+		}
+		return nil
+	}))
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{MatchBody: []byte(`ping`), Data: []byte(`exact`)},
+		MockResp{MatchBody: []byte(`{"a": 1}`), BodyComparator: CompareJSON, Data: []byte(`json`)},
+		MockResp{MatchBody: []byte(`pong`), BodyComparator: "trimmed", Data: []byte(`trimmed`)},
+	})
+
+	post := func(body string) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/bla", strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Equal(t, "trimmed", post(" pong\n"))
+	assert.Equal(t, "json", post(`{ "a":1 }`))
+	assert.Equal(t, "exact", post(`ping`))
+
+	req, _ := http.NewRequest(http.MethodPost, "/bla", strings.NewReader(`{"a": 2}`))
+	assert.Equal(t, `body differs: got "{\"a\": 2}", want "ping"`, mrClient.mismatch(&MockResp{MatchBody: []byte(`ping`)}, req))
+	req, _ = http.NewRequest(http.MethodPost, "/bla", strings.NewReader(`{"a": 2}`))
+	assert.Equal(t, `body differs: $.a: got 2, want 1`,
+		mrClient.mismatch(&MockResp{MatchBody: []byte(`{"a": 1}`), BodyComparator: CompareJSON}, req))
+	req, _ = http.NewRequest(http.MethodPost, "/bla", strings.NewReader(`{"a": 2}`))
+	assert.Equal(t, `body differs: $.a: got 2, want 1`, mrClient.mismatch(&MockResp{MatchBody: []byte(`{"a": 1}`)}, req))
+	req, _ = http.NewRequest(http.MethodPost, "/bla", strings.NewReader(`{"a": 1}`))
+	assert.Equal(t, `body differs: got "{\"a\": 1}", want "{\"a\":1}"`, mrClient.mismatch(&MockResp{MatchBody: []byte(`{"a":1}`)}, req))
+
+	assert.EqualError(t, mrClient.patternError(&MockResp{MatchBody: []byte(`x`), BodyComparator: "proto"}),
+		`unknown body comparator "proto"`)
+}
@@ -0,0 +1,50 @@
+package mockresponder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ContextCheck describes the properties the context of a request served by a
+// response must have, see MockResp.CheckContext.  Every property the context
+// lacks is recorded as a violation, see Violations().
+type ContextCheck struct {
+	// Deadline requires the context to have a deadline.
+	Deadline bool
+	// MaxTimeout requires the context to have a deadline which is at most
+	// the given duration away when the request arrives.
+	MaxTimeout time.Duration
+	// Check is called with the context and returns an error if the context
+	// lacks a property, e.g. a tracing span.
+	Check func(ctx context.Context) error
+}
+
+// checkContext records a violation for every property the context of the
+// request served by data lacks.
+func (m *MockResponder) checkContext(data *MockResp, req *http.Request) {
+	cc := data.CheckContext
+	if cc == nil {
+		return
+	}
+	ctx := req.Context()
+	where := fmt.Sprintf("request %s %s", req.Method, sanitizeURL(req.URL.String()))
+	deadline, ok := ctx.Deadline()
+	switch {
+	case !ok && (cc.Deadline || cc.MaxTimeout > 0):
+		m.violate("%s has no context deadline", where)
+	case ok && cc.MaxTimeout > 0:
+		if left := time.Until(deadline); left > cc.MaxTimeout {
+			m.violate("%s has a context deadline %s away, exceeding %s", where, left.Round(time.Millisecond), cc.MaxTimeout)
+		}
+	}
+	if cc.Check != nil {
+		check := cc.Check
+		var err error
+		what := func() string { return "context check of " + m.describe(data) }
+		if m.guard(what, func() { err = check(ctx) }) == nil && err != nil {
+			m.violate("%s has an invalid context: %s", where, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package mockresponder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type spanKey struct{}
+
+func TestMockResp_CheckContext(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	check := &ContextCheck{
+		MaxTimeout: 5 * time.Second,
+		Check: func(ctx context.Context) error {
+			if ctx.Value(spanKey{}) == nil {
+				return errors.New("no span")
+			}
+			return nil
+		},
+	}
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/a$", CheckContext: check},
+		MockResp{URL: "/a$", CheckContext: check},
+		MockResp{URL: "/a$", CheckContext: check},
+		MockResp{URL: "/b$", CheckContext: &ContextCheck{Deadline: true}},
+	})
+
+	get := func(ctx context.Context, path string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla"+path, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	tctx, cancel := context.WithTimeout(context.WithValue(ctx, spanKey{}, "span"), time.Second)
+	defer cancel()
+	get(tctx, "/a")
+	assert.Empty(t, mrClient.Violations())
+
+	lctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	get(lctx, "/a")
+	get(ctx, "/a")
+	get(ctx, "/b")
+	violations := mrClient.Violations()
+	assert.Len(t, violations, 5)
+	assert.Regexp(t, `^request GET http://bla/a has a context deadline .* away, exceeding 5s$`, violations[0])
+	assert.Equal(t, []string{
+		"request GET http://bla/a has an invalid context: no span",
+		"request GET http://bla/a has no context deadline",
+		"request GET http://bla/a has an invalid context: no span",
+		"request GET http://bla/b has no context deadline",
+	}, violations[1:])
+}
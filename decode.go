@@ -0,0 +1,139 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var errNoRequest = errors.New("no request captured")
+
+// LastRequest returns the request which was served last.
+func (m *MockResponder) LastRequest() (CapturedRequest, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	history := m.interactions()
+	if len(history) == 0 {
+		return CapturedRequest{}, false
+	}
+	return history[len(history)-1].Request, true
+}
+
+// DecodeLastRequestJSON decodes the JSON body of the last served request into
+// v.
+func (m *MockResponder) DecodeLastRequestJSON(v any) error {
+	req, ok := m.LastRequest()
+	if !ok {
+		return errNoRequest
+	}
+	return json.Unmarshal(req.Body, v)
+}
+
+// DecodeLastRequestQuery decodes the query of the last served request into v,
+// which must be a pointer to a struct or to url.Values.  Struct fields are
+// taken from the query parameter named in their `query` tag or, without tag,
+// the parameter matching the field name case-insensitively.  Supported field
+// types are strings, booleans, numbers and slices of those.
+func (m *MockResponder) DecodeLastRequestQuery(v any) error {
+	req, ok := m.LastRequest()
+	if !ok {
+		return errNoRequest
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return err
+	}
+	return decodeQuery(u.Query(), v)
+}
+
+func decodeQuery(query url.Values, v any) error {
+	if values, ok := v.(*url.Values); ok {
+		*values = query
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can't decode query into %T", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, tagged := field.Tag.Lookup("query")
+		if name == "-" {
+			continue
+		}
+		var values []string
+		if tagged {
+			values = query[name]
+		} else {
+			for k, vs := range query {
+				if strings.EqualFold(k, field.Name) {
+					values = vs
+					break
+				}
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if err := setValue(rv.Field(i), values); err != nil {
+			return fmt.Errorf("query parameter for %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// setValue sets the field to the given values, only slices take more than the
+// first value.
+func setValue(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setValue(slice.Index(i), []string{s}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	s := values[0]
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package mockresponder
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_DecodeLastRequest(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{}})
+
+	var query struct {
+		Page    int `query:"page"`
+		PerPage uint
+		Tags    []string `query:"tag"`
+		Active  bool
+		Ratio   float64
+		Skipped string `query:"-"`
+		ignored string
+	}
+	assert.ErrorIs(t, mrClient.DecodeLastRequestQuery(&query), errNoRequest)
+	assert.ErrorIs(t, mrClient.DecodeLastRequestJSON(&query), errNoRequest)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost,
+		"http://bla/devices?page=2&perpage=50&tag=a&tag=b&active=true&ratio=0.5&skipped=x",
+		strings.NewReader(`{"name": "router", "ports": [1, 2]}`))
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.NoError(t, mrClient.DecodeLastRequestQuery(&query))
+	assert.Equal(t, 2, query.Page)
+	assert.Equal(t, uint(50), query.PerPage)
+	assert.Equal(t, []string{"a", "b"}, query.Tags)
+	assert.True(t, query.Active)
+	assert.Equal(t, 0.5, query.Ratio)
+	assert.Empty(t, query.Skipped)
+	assert.Empty(t, query.ignored)
+
+	var values url.Values
+	assert.NoError(t, mrClient.DecodeLastRequestQuery(&values))
+	assert.Equal(t, "2", values.Get("page"))
+
+	var body struct {
+		Name  string
+		Ports []int
+	}
+	assert.NoError(t, mrClient.DecodeLastRequestJSON(&body))
+	assert.Equal(t, "router", body.Name)
+	assert.Equal(t, []int{1, 2}, body.Ports)
+
+	var bad struct {
+		Page bool `query:"page"`
+	}
+	assert.ErrorContains(t, mrClient.DecodeLastRequestQuery(&bad), "query parameter for Page")
+	assert.ErrorContains(t, mrClient.DecodeLastRequestQuery(query), "can't decode query into")
+}
@@ -0,0 +1,70 @@
+package mockresponder
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ResponderHeader is the request header which identifies the mock responder
+// of requests attached via AttachResponder.
+const ResponderHeader = "X-Mock-Responder"
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*MockResponder{}
+	lastID     int
+)
+
+// AttachResponder returns a copy of the request which is bound to the mock
+// responder, both via the request context and the ResponderHeader.  The header
+// keeps the binding intact when frameworks or middleware replace the request
+// context, e.g. by rebuilding the request with a fresh context.  The header
+// binding is released when the responder is closed, which NewMockResponderT
+// does once the test has finished.  Other callers must call Close, otherwise
+// the responder stays registered for the lifetime of the process.
+func AttachResponder(req *http.Request, m *MockResponder) *http.Request {
+	m.mu.Lock()
+	if len(m.id) == 0 {
+		registryMu.Lock()
+		lastID++
+		m.id = strconv.Itoa(lastID)
+		registry[m.id] = m
+		registryMu.Unlock()
+	}
+	id := m.id
+	m.mu.Unlock()
+
+	req = req.Clone(context.WithValue(req.Context(), contextMockClient, m))
+	req.Header.Set(ResponderHeader, id)
+	return req
+}
+
+// discover returns the value stored under the context key of the request or,
+// if there's none, the responder identified by the ResponderHeader.
+func discover(req *http.Request) any {
+	if v := req.Context().Value(contextMockClient); v != nil {
+		return v
+	}
+	id := req.Header.Get(ResponderHeader)
+	if len(id) == 0 {
+		return nil
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if m, ok := registry[id]; ok {
+		return m
+	}
+	return nil
+}
+
+// unregister releases the header binding of the responder.
+func (m *MockResponder) unregister() {
+	if len(m.id) == 0 {
+		return
+	}
+	registryMu.Lock()
+	delete(registry, m.id)
+	registryMu.Unlock()
+}
@@ -0,0 +1,45 @@
+package mockresponder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachResponder(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{Data: []byte(`OK`)}, MockResp{}})
+
+	req, _ := http.NewRequest(http.MethodGet, "/bla", nil)
+	attached := AttachResponder(req, mrClient)
+	assert.Empty(t, req.Header.Get(ResponderHeader))
+	assert.NotEmpty(t, attached.Header.Get(ResponderHeader))
+
+	// middleware rebuilds the request with a fresh context
+	rebuilt := attached.Clone(context.Background())
+	resp, err := mrClient.Do(rebuilt)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []byte(`OK`), mrClient.LastData())
+
+	// a second attach reuses the identifier
+	assert.Equal(t, attached.Header.Get(ResponderHeader), AttachResponder(req, mrClient).Header.Get(ResponderHeader))
+
+	mrClient.Close()
+	assert.Nil(t, discover(rebuilt))
+}
+
+func TestAttachResponder_cleanup(t *testing.T) {
+	var attached *http.Request
+	t.Run("test", func(t *testing.T) {
+		mrClient, _ := NewMockResponderT(t)
+		req, _ := http.NewRequest(http.MethodGet, "/bla", nil)
+		attached = AttachResponder(req, mrClient)
+		assert.NotNil(t, discover(attached.Clone(context.Background())))
+	})
+
+	// the binding is released once the test has finished
+	assert.Nil(t, discover(attached.Clone(context.Background())))
+}
@@ -0,0 +1,176 @@
+package mockresponder
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// supportedEncodings lists the content encodings in order of preference.
+var supportedEncodings = []string{"gzip", "deflate", "identity"}
+
+// accepted is a value of an Accept-* header with its quality.
+type accepted struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses an Accept-* header into its lower-cased values ordered by
+// descending quality.  Values with the same quality keep their order.
+func parseAccept(header string) []accepted {
+	var list []accepted
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if k == "q" {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		list = append(list, accepted{value: name, q: q})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].q > list[j].q
+	})
+	return list
+}
+
+// negotiateEncoding returns the supported content encoding with the highest
+// quality value in the given Accept-Encoding header.  Without a header, or if
+// nothing else is acceptable, identity is returned.
+func negotiateEncoding(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return "identity"
+	}
+	quality := make(map[string]float64)
+	for _, a := range parseAccept(accept) {
+		quality[a.value] = a.q
+	}
+
+	best, bestQ := "identity", 0.0
+	for _, enc := range supportedEncodings {
+		q, ok := quality[enc]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if !ok && enc == "identity" {
+			// identity is acceptable unless explicitly excluded
+			q = 0.001
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// encodeBody encodes the body according to the Accept-Encoding header of the
+// request and sets the accompanying response headers.
+func encodeBody(req *http.Request, header http.Header, body []byte) ([]byte, error) {
+	enc := negotiateEncoding(req.Header.Get("Accept-Encoding"))
+	header.Add("Vary", "Accept-Encoding")
+
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch enc {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	default:
+		return body, nil
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	header.Set("Content-Encoding", enc)
+	return buf.Bytes(), nil
+}
+
+// payload returns the body of the request with the content encodings set by
+// the client (gzip and deflate) removed, so that matchers and captures operate
+// on the logical payload.  URL-encoded form bodies are canonicalized, see
+// canonicalForm.  The body of the request itself is left as sent.  If an
+// encoding is unknown or the body can't be decoded, the body is returned as
+// is.
+func payload(req *http.Request) []byte {
+	body := decompress(req)
+	if isForm(req) {
+		return canonicalForm(body)
+	}
+	return body
+}
+
+// isForm returns true if the request has a URL-encoded form body.
+func isForm(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// canonicalForm returns the URL-encoded form with sorted keys and uniformly
+// encoded values so that bodies built by different encoders (net/url or
+// manual string building) are equal.  The order of repeated values of a key is
+// kept.  Invalid forms are returned as is.
+func canonicalForm(body []byte) []byte {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+	return []byte(values.Encode())
+}
+
+// decompress returns the body of the request with the content encodings set by
+// the client removed.
+func decompress(req *http.Request) []byte {
+	body := readBody(req)
+	encodings := strings.Split(req.Header.Get("Content-Encoding"), ",")
+	decoded := body
+	// encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var (
+			r   io.Reader
+			err error
+		)
+		switch strings.ToLower(strings.TrimSpace(encodings[i])) {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(bytes.NewReader(decoded))
+		case "deflate":
+			r, err = zlib.NewReader(bytes.NewReader(decoded))
+			if err != nil {
+				// some clients send raw deflate data without the zlib wrapper
+				r, err = flate.NewReader(bytes.NewReader(decoded)), nil
+			}
+		default:
+			return body
+		}
+		if err != nil {
+			return body
+		}
+		if decoded, err = io.ReadAll(r); err != nil {
+			return body
+		}
+	}
+	return decoded
+}
@@ -0,0 +1,146 @@
+package mockresponder
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_negotiateEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"none", "", "identity"},
+		{"gzip", "gzip", "gzip"},
+		{"deflate", "deflate, br", "deflate"},
+		{"preferred", "gzip, deflate", "gzip"},
+		{"quality", "gzip;q=0.5, deflate", "deflate"},
+		{"identity", "identity", "identity"},
+		{"unsupported", "br", "identity"},
+		{"wildcard", "*", "gzip"},
+		{"excluded", "gzip;q=0, deflate;q=0", "identity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, negotiateEncoding(tt.accept))
+		})
+	}
+}
+
+func TestMockResponder_SetContentEncoding(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetContentEncoding(true)
+	data := []byte(`{"hello": "world"}`)
+
+	tests := []struct {
+		name   string
+		accept string
+		reader func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"deflate", "deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{"identity", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrClient.SetData(MockRespList{MockResp{Data: data}})
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			resp, err := mrClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+
+			var r io.Reader = resp.Body
+			if tt.reader != nil {
+				assert.Equal(t, tt.accept, resp.Header.Get("Content-Encoding"))
+				r, err = tt.reader(resp.Body)
+				assert.NoError(t, err)
+			} else {
+				assert.Empty(t, resp.Header.Get("Content-Encoding"))
+			}
+			body, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, data, body)
+		})
+	}
+}
+
+func Test_payload(t *testing.T) {
+	compress := func(encoding string, data string) []byte {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "deflate":
+			w = zlib.NewWriter(&buf)
+		case "raw":
+			w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		}
+		w.Write([]byte(data))
+		w.Close()
+		return buf.Bytes()
+	}
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		want     string
+	}{
+		{"identity", "", []byte(`{"id": 1}`), `{"id": 1}`},
+		{"gzip", "gzip", compress("gzip", `{"id": 1}`), `{"id": 1}`},
+		{"deflate", "deflate", compress("deflate", `{"id": 1}`), `{"id": 1}`},
+		{"raw deflate", "deflate", compress("raw", `{"id": 1}`), `{"id": 1}`},
+		{"stacked", "deflate, gzip", compress("gzip", string(compress("deflate", `{"id": 1}`))), `{"id": 1}`},
+		{"broken", "gzip", []byte(`{"id": 1}`), `{"id": 1}`},
+		{"unknown", "br", []byte(`xyz`), `xyz`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/bla", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			assert.Equal(t, tt.want, string(payload(req)))
+			// the request body is left as sent
+			body, _ := io.ReadAll(req.Body)
+			assert.Equal(t, tt.body, body)
+		})
+	}
+
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/bla", bytes.NewReader(compress("gzip", "hello")))
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []byte("hello"), mrClient.History()[0].Request.Body)
+}
+
+func Test_canonicalForm(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{BodyPattern: `^grant_type=password&scope=a\+b&user=~jo$`}})
+
+	// built manually, keys out of order and with different escaping
+	body := "user=%7Ejo&scope=a%20b&grant_type=password"
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/token", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "grant_type=password&scope=a+b&user=~jo", string(mrClient.History()[0].Request.Body))
+
+	assert.Equal(t, []byte("a=2&a=1&b=3"), canonicalForm([]byte("b=3&a=2&a=1")))
+	assert.Equal(t, []byte("a=%zz"), canonicalForm([]byte("a=%zz")))
+}
@@ -0,0 +1,12 @@
+package mockresponder
+
+import "net/http"
+
+// checkFinal records a violation if the request arrived after a response
+// marked as Final has been served.
+func (m *MockResponder) checkFinal(req *http.Request) {
+	if len(m.final) > 0 {
+		m.violate("request %s %s arrived after the final response %s was served",
+			req.Method, sanitizeURL(req.URL.String()), m.final)
+	}
+}
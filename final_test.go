@@ -0,0 +1,38 @@
+package mockresponder
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResp_Final(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/data$"},
+		MockResp{URL: "/logout$", Name: "logout", Final: true},
+		MockResp{URL: "/data$"},
+	})
+
+	get := func(path string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://api.example.com"+path, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	get("/data")
+	get("/logout")
+	assert.Empty(t, mrClient.Violations())
+
+	get("/data")
+	assert.Equal(t, []string{
+		"request GET http://api.example.com/data arrived after the final response logout/1 was served",
+	}, mrClient.Violations())
+
+	// resetting allows to serve the responses again
+	mrClient.ResetAll()
+	get("/data")
+	get("/logout")
+	assert.Empty(t, mrClient.Violations())
+}
@@ -0,0 +1,271 @@
+package mockresponder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// fixture is the representation of a mocked response in a fixture file.
+type fixture struct {
+	Name             string                 `json:"name,omitempty"`
+	Tag              string                 `json:"tag,omitempty"`
+	Priority         int                    `json:"priority,omitempty"`
+	URL              string                 `json:"url,omitempty"`
+	NotURL           string                 `json:"notURL,omitempty"`
+	PathTemplate     string                 `json:"pathTemplate,omitempty"`
+	Glob             bool                   `json:"glob,omitempty"`
+	Method           string                 `json:"method,omitempty"`
+	Host             string                 `json:"host,omitempty"`
+	Scheme           string                 `json:"scheme,omitempty"`
+	Proto            string                 `json:"proto,omitempty"`
+	MatchHeaders     map[string]string      `json:"matchHeaders,omitempty"`
+	MatchTrailers    map[string]string      `json:"matchTrailers,omitempty"`
+	MatchCookies     map[string]string      `json:"matchCookies,omitempty"`
+	MatchBasicAuth   *fixtureCredentials    `json:"matchBasicAuth,omitempty"`
+	MatchBearerToken string                 `json:"matchBearerToken,omitempty"`
+	MatchContentType string                 `json:"matchContentType,omitempty"`
+	BodyPattern      string                 `json:"bodyPattern,omitempty"`
+	MatchBody        string                 `json:"matchBody,omitempty"`
+	BodyComparator   string                 `json:"bodyComparator,omitempty"`
+	MatchJSON        json.RawMessage        `json:"matchJSON,omitempty"`
+	MatchXML         string                 `json:"matchXML,omitempty"`
+	MatchForm        map[string][]string    `json:"matchForm,omitempty"`
+	MatchMultipart   *fixtureMultipart      `json:"matchMultipart,omitempty"`
+	SOAPAction       string                 `json:"soapAction,omitempty"`
+	SOAPOperation    string                 `json:"soapOperation,omitempty"`
+	SeqHeader        string                 `json:"seqHeader,omitempty"`
+	SeqField         string                 `json:"seqField,omitempty"`
+	ValidFor         string                 `json:"validFor,omitempty"`
+	Final            bool                   `json:"final,omitempty"`
+	Code             int                    `json:"code,omitempty"`
+	Header           map[string]string      `json:"header,omitempty"`
+	Body             string                 `json:"body,omitempty"`
+	DataFile         string                 `json:"dataFile,omitempty"`
+	JSON             json.RawMessage        `json:"json,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+	Languages        map[string]string      `json:"languages,omitempty"`
+	LanguageFallback string                 `json:"languageFallback,omitempty"`
+	BodyError        string                 `json:"bodyError,omitempty"`
+	BodyHang         bool                   `json:"bodyHang,omitempty"`
+	Raw              string                 `json:"raw,omitempty"`
+	Creates          string                 `json:"creates,omitempty"`
+	Informational    []fixtureInformational `json:"informational,omitempty"`
+	Delay            string                 `json:"delay,omitempty"`
+	DelayMin         string                 `json:"delayMin,omitempty"`
+	DelayMax         string                 `json:"delayMax,omitempty"`
+}
+
+// fixtureCredentials is the representation of Credentials in a fixture file.
+type fixtureCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// fixtureMultipart is the representation of a MultipartMatch in a fixture
+// file.
+type fixtureMultipart struct {
+	Fields       map[string]string `json:"fields,omitempty"`
+	Files        []string          `json:"files,omitempty"`
+	ContentTypes map[string]string `json:"contentTypes,omitempty"`
+}
+
+// fixtureInformational is the representation of an Informational response
+// in a fixture file.
+type fixtureInformational struct {
+	Code   int               `json:"code"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+// LoadFixtures reads mocked responses from a fixture file, see ParseFixtures.
+// A relative dataFile is resolved relative to the directory of the fixture
+// file.
+func LoadFixtures(path string, vars map[string]any) (MockRespList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := ParseFixtures(f, vars)
+	if err != nil {
+		return nil, err
+	}
+	for i := range data {
+		if len(data[i].DataFile) > 0 && !filepath.IsAbs(data[i].DataFile) {
+			data[i].DataFile = filepath.Join(filepath.Dir(path), data[i].DataFile)
+		}
+	}
+	return data, nil
+}
+
+// ParseFixtures reads mocked responses in JSON format from r.  The fixtures
+// are a list of objects with the optional fields name, tag, priority, url,
+// notURL, pathTemplate, glob, method, host, scheme, proto, matchHeaders,
+// matchTrailers, matchCookies, matchBasicAuth, matchBearerToken,
+// matchContentType, bodyPattern, matchBody, bodyComparator, matchJSON,
+// matchXML, matchForm, matchMultipart, soapAction, soapOperation, seqHeader,
+// seqField, validFor, final, code, header, body, dataFile, json, error,
+// languages, languageFallback, bodyError, bodyHang, raw, creates,
+// informational, delay, delayMin and delayMax, named like the fields of
+// MockResp.  json is an arbitrary JSON value which is used as the body,
+// dataFile is the path of a file which is served as the body, matchJSON is
+// the JSON value the request body must be equal to, languages maps language
+// tags to body variants, matchBasicAuth is an object with a username and a
+// password, matchMultipart an object with fields, files and contentTypes and
+// informational a list of objects with a code and a header.  Durations like
+// validFor and delay are strings like "1.5s", see time.ParseDuration.  See
+// FixtureSchema.
+//
+// Before parsing, the fixtures are executed as a text/template with vars as
+// data.  This allows to use the same fixtures with different identifiers, e.g.
+// "url": "/tenants/{{.tenant}}/devices/{{.baseID}}$".  Values are substituted
+// verbatim and referring to a missing variable is an error.
+func ParseFixtures(r io.Reader, vars map[string]any) (MockRespList, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("fixtures").Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, err
+	}
+
+	var fixtures []fixture
+	if err := json.Unmarshal(buf.Bytes(), &fixtures); err != nil {
+		return nil, fmt.Errorf("fixtures: %w", err)
+	}
+	list := make(MockRespList, 0, len(fixtures))
+	for i, f := range fixtures {
+		mr, err := f.mockResp()
+		if err != nil {
+			return nil, fmt.Errorf("fixture %d: %w", i, err)
+		}
+		list = append(list, mr)
+	}
+	return list, nil
+}
+
+func (f fixture) mockResp() (MockResp, error) {
+	mr := MockResp{
+		Name:             f.Name,
+		Tag:              f.Tag,
+		Priority:         f.Priority,
+		URL:              f.URL,
+		NotURL:           f.NotURL,
+		PathTemplate:     f.PathTemplate,
+		Glob:             f.Glob,
+		Method:           f.Method,
+		Host:             f.Host,
+		Scheme:           f.Scheme,
+		Proto:            f.Proto,
+		MatchHeaders:     f.MatchHeaders,
+		MatchTrailers:    f.MatchTrailers,
+		MatchCookies:     f.MatchCookies,
+		MatchBearerToken: f.MatchBearerToken,
+		MatchContentType: f.MatchContentType,
+		BodyPattern:      f.BodyPattern,
+		BodyComparator:   f.BodyComparator,
+		SOAPAction:       f.SOAPAction,
+		SOAPOperation:    f.SOAPOperation,
+		SeqHeader:        f.SeqHeader,
+		SeqField:         f.SeqField,
+		Final:            f.Final,
+		Code:             f.Code,
+		Creates:          f.Creates,
+		Data:             []byte(f.Body),
+		DataFile:         f.DataFile,
+		BodyHang:         f.BodyHang,
+	}
+	if len(f.JSON) > 0 {
+		mr.Data = []byte(f.JSON)
+	}
+	if len(f.MatchBody) > 0 {
+		mr.MatchBody = []byte(f.MatchBody)
+	}
+	if len(f.MatchJSON) > 0 {
+		mr.MatchJSON = f.MatchJSON
+	}
+	if len(f.MatchXML) > 0 {
+		mr.MatchXML = []byte(f.MatchXML)
+	}
+	if len(f.MatchForm) > 0 {
+		mr.MatchForm = url.Values(f.MatchForm)
+	}
+	if f.MatchBasicAuth != nil {
+		mr.MatchBasicAuth = &Credentials{Username: f.MatchBasicAuth.Username, Password: f.MatchBasicAuth.Password}
+	}
+	if f.MatchMultipart != nil {
+		mr.MatchMultipart = &MultipartMatch{
+			Fields:       f.MatchMultipart.Fields,
+			Files:        f.MatchMultipart.Files,
+			ContentTypes: f.MatchMultipart.ContentTypes,
+		}
+	}
+	if len(f.Header) > 0 {
+		mr.Header = fixtureHeader(f.Header)
+	}
+	if len(f.Error) > 0 {
+		mr.Err = errors.New(f.Error)
+	}
+	if len(f.BodyError) > 0 {
+		mr.BodyErr = errors.New(f.BodyError)
+	}
+	if len(f.Raw) > 0 {
+		mr.Raw = []byte(f.Raw)
+	}
+	if len(f.Languages) > 0 {
+		mr.Languages = make(map[string][]byte)
+		for tag, body := range f.Languages {
+			mr.Languages[tag] = []byte(body)
+		}
+		mr.LanguageFallback = f.LanguageFallback
+	}
+	for _, i := range f.Informational {
+		mr.Informational = append(mr.Informational, Informational{Code: i.Code, Header: fixtureHeader(i.Header)})
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"validFor", f.ValidFor, &mr.ValidFor},
+		{"delay", f.Delay, &mr.Delay},
+		{"delayMin", f.DelayMin, &mr.DelayMin},
+		{"delayMax", f.DelayMax, &mr.DelayMax},
+	} {
+		if len(d.value) == 0 {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return MockResp{}, fmt.Errorf("%s: %w", d.name, err)
+		}
+		*d.dst = v
+	}
+	return mr, nil
+}
+
+// fixtureHeader returns the header with the given values, nil if there are
+// none.
+func fixtureHeader(values map[string]string) http.Header {
+	if len(values) == 0 {
+		return nil
+	}
+	header := make(http.Header)
+	for k, v := range values {
+		header.Set(k, v)
+	}
+	return header
+}
@@ -0,0 +1,151 @@
+package mockresponder
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testFixtures = `[
+	{
+		"name": "device",
+		"url": "/tenants/{{.tenant}}/devices/{{.baseID}}$",
+		"header": {"Content-Type": "application/json"},
+		"json": {"id": "{{.baseID}}", "tenant": "{{.tenant}}"}
+	},
+	{
+		"url": "/tenants/{{.tenant}}/devices$",
+		"code": 201,
+		"body": "created"
+	},
+	{
+		"error": "ka-boom"
+	}
+]`
+
+func TestLoadFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	assert.NoError(t, os.WriteFile(path, []byte(testFixtures), 0o600))
+
+	tests := []struct {
+		tenant string
+		baseID string
+	}{
+		{"acme", "1234"},
+		{"initech", "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			data, err := LoadFixtures(path, map[string]any{"tenant": tt.tenant, "baseID": tt.baseID})
+			assert.NoError(t, err)
+			assert.Len(t, data, 3)
+			assert.Equal(t, http.StatusCreated, data[1].Code)
+			assert.Equal(t, []byte("created"), data[1].Data)
+			assert.EqualError(t, data[2].Err, "ka-boom")
+
+			mrClient, ctx := NewMockResponder()
+			mrClient.SetData(data)
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/tenants/"+tt.tenant+"/devices/"+tt.baseID, nil)
+			resp, err := mrClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			assert.JSONEq(t, `{"id": "`+tt.baseID+`", "tenant": "`+tt.tenant+`"}`, string(body))
+		})
+	}
+
+	_, err := LoadFixtures(path, map[string]any{"tenant": "acme"})
+	assert.ErrorContains(t, err, `map has no entry for key "baseID"`)
+	_, err = LoadFixtures(filepath.Join(t.TempDir(), "nope.json"), nil)
+	assert.Error(t, err)
+	_, err = ParseFixtures(strings.NewReader(`{{`), nil)
+	assert.Error(t, err)
+	_, err = ParseFixtures(strings.NewReader(`{}`), nil)
+	assert.ErrorContains(t, err, "fixtures: json: cannot unmarshal")
+}
+
+func TestParseFixtures_Matchers(t *testing.T) {
+	data, err := ParseFixtures(strings.NewReader(`[{
+		"url": "/login$",
+		"notURL": "/health$",
+		"matchCookies": {"session": "^abc"},
+		"matchBasicAuth": {"username": "admin", "password": "secret"},
+		"matchBearerToken": "token",
+		"matchContentType": "application/x-www-form-urlencoded",
+		"matchForm": {"user": ["admin"]},
+		"matchXML": "<login/>",
+		"matchMultipart": {"fields": {"name": "x"}, "files": ["upload"]},
+		"validFor": "1m",
+		"final": true,
+		"bodyError": "reset",
+		"informational": [{"code": 103, "header": {"Link": "</a.css>"}}],
+		"delay": "10ms",
+		"delayMin": "1ms",
+		"delayMax": "2ms"
+	}]`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, MockResp{
+		URL:              "/login$",
+		NotURL:           "/health$",
+		MatchCookies:     map[string]string{"session": "^abc"},
+		MatchBasicAuth:   &Credentials{Username: "admin", Password: "secret"},
+		MatchBearerToken: "token",
+		MatchContentType: "application/x-www-form-urlencoded",
+		MatchForm:        url.Values{"user": []string{"admin"}},
+		MatchXML:         []byte("<login/>"),
+		MatchMultipart:   &MultipartMatch{Fields: map[string]string{"name": "x"}, Files: []string{"upload"}},
+		ValidFor:         time.Minute,
+		Final:            true,
+		Data:             []byte{},
+		BodyErr:          errors.New("reset"),
+		Informational:    []Informational{{Code: http.StatusEarlyHints, Header: http.Header{"Link": []string{"</a.css>"}}}},
+		Delay:            10 * time.Millisecond,
+		DelayMin:         time.Millisecond,
+		DelayMax:         2 * time.Millisecond,
+	}, data[0])
+
+	_, err = ParseFixtures(strings.NewReader(`[{}, {"delay": "soon"}]`), nil)
+	assert.ErrorContains(t, err, "fixture 1: delay: time: invalid duration")
+}
+
+func TestLoadFixtures_DataFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "bodies"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "bodies", "dump.json"), []byte(`{"devices": []}`), 0o600))
+	path := filepath.Join(dir, "fixtures.json")
+	abs := filepath.Join(t.TempDir(), "abs.json")
+	fixtures := `[{"url": "/dump$", "dataFile": "bodies/dump.json"}, {"dataFile": "` + abs + `"}]`
+	assert.NoError(t, os.WriteFile(path, []byte(fixtures), 0o600))
+
+	data, err := LoadFixtures(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "bodies", "dump.json"), data[0].DataFile)
+	assert.Equal(t, abs, data[1].DataFile)
+
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(data)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/dump", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"devices": []}`, string(body))
+}
+
+func TestParseFixtures_Languages(t *testing.T) {
+	data, err := ParseFixtures(strings.NewReader(`[
+		{"body": "ciao", "languages": {"en": "hello", "de": "hallo"}, "languageFallback": "en"}
+	]`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"en": []byte("hello"), "de": []byte("hallo")}, data[0].Languages)
+	assert.Equal(t, "en", data[0].LanguageFallback)
+}
@@ -0,0 +1,61 @@
+package mockresponder
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetHookTimeout sets the time user-provided functions, like the Matcher of a
+// response, body comparators, match strategies, context checks, data and
+// respond functions, API handlers and the function set via SetDoFunc, may
+// take per call.  A function which doesn't return in time records a
+// violation naming the function, instead of deadlocking the responder and the
+// whole suite: a timed out matcher or comparator doesn't match, a timed out
+// match strategy selects by priority and the other timed out functions fail
+// the request with ErrHookTimeout.  A timeout of 0, the default, disables the
+// guard.
+func (m *MockResponder) SetHookTimeout(d time.Duration) {
+	m.mu.Lock()
+	m.hookTimeout = d
+	m.mu.Unlock()
+}
+
+// guard calls fn and waits for it to return at most the hook timeout, what
+// describes fn.  Panics of fn are propagated.
+func (m *MockResponder) guard(what func() string, fn func()) error {
+	if m.hookTimeout <= 0 {
+		fn()
+		return nil
+	}
+	done := make(chan any, 1)
+	go func() {
+		defer func() {
+			done <- recover()
+		}()
+		fn()
+	}()
+	t := time.NewTimer(m.hookTimeout)
+	defer t.Stop()
+	select {
+	case p := <-done:
+		if p != nil {
+			panic(p)
+		}
+		return nil
+	case <-t.C:
+		err := fmt.Errorf("%w: %s did not return within %s", ErrHookTimeout, what(), m.hookTimeout)
+		m.violate("%s", err)
+		return err
+	}
+}
+
+// describe returns a description of the response for messages, e.g.
+// `response 2 ("login", "/auth$")`.
+func (m *MockResponder) describe(data *MockResp) string {
+	for i := range m.mockData {
+		if &m.mockData[i] == data {
+			return fmt.Sprintf("response %d (%q, %q)", i, data.Name, data.URL)
+		}
+	}
+	return fmt.Sprintf("response (%q, %q)", data.Name, data.URL)
+}
@@ -0,0 +1,51 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetHookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hang := func(*http.Request) bool {
+		<-release
+		return true
+	}
+
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetHookTimeout(20 * time.Millisecond)
+	mrClient.SetData(MockRespList{
+		MockResp{Name: "buggy", URL: "/devices$", Matcher: hang, Data: []byte(`buggy`)},
+		MockResp{URL: "/devices$", Data: []byte(`ok`)},
+	})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/devices", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, []string{
+		`hook timed out: matcher of response 0 ("buggy", "/devices$") did not return within 20ms`,
+	}, mrClient.Violations())
+
+	mrClient.SetDoFunc(func(*http.Request) (*http.Response, error) {
+		<-release
+		return nil, nil
+	})
+	_, err = mrClient.Do(req)
+	assert.ErrorIs(t, err, ErrHookTimeout)
+	assert.EqualError(t, err, "hook timed out: custom do function did not return within 20ms")
+
+	// panics are propagated
+	mrClient.SetDoFunc(func(*http.Request) (*http.Response, error) {
+		panic("ka-boom")
+	})
+	assert.PanicsWithValue(t, "ka-boom", func() {
+		_, _ = mrClient.Do(req)
+	})
+}
@@ -0,0 +1,214 @@
+package mockresponder
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"regexp"
+)
+
+// CSVResp returns a response with the given records encoded as CSV and the
+// Content-Type set to text/csv.
+func CSVResp(records [][]string) MockResp {
+	return delimitedResp(records, ',', "text/csv; charset=utf-8")
+}
+
+// TSVResp returns a response with the given records encoded as tab separated
+// values and the Content-Type set to text/tab-separated-values.
+func TSVResp(records [][]string) MockResp {
+	return delimitedResp(records, '\t', "text/tab-separated-values; charset=utf-8")
+}
+
+func delimitedResp(records [][]string, comma rune, contentType string) MockResp {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	// writing to a buffer can't fail
+	w.WriteAll(records)
+	return MockResp{
+		Data:   buf.Bytes(),
+		Header: http.Header{"Content-Type": []string{contentType}},
+	}
+}
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type   string `json:"type,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Status int    `json:"status,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ProblemResp returns an RFC 7807 error response with the given status code and
+// problem details, using the application/problem+json content type.  Empty
+// details are omitted.
+func ProblemResp(status int, typ, title, detail string) MockResp {
+	// marshalling a struct of strings can't fail
+	data, _ := json.Marshal(Problem{
+		Type:   typ,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	})
+	return MockResp{
+		Data:   data,
+		Code:   status,
+		Header: http.Header{"Content-Type": []string{"application/problem+json"}},
+	}
+}
+
+// JSONResp returns a response with v marshalled as JSON and the Content-Type
+// set to application/json.  It panics if v can't be marshalled.
+func JSONResp(v any) MockResp {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("can't marshal JSON response: %s", err))
+	}
+	return MockResp{
+		Data:   data,
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+	}
+}
+
+// batchBoundary separates the parts of batch responses.
+const batchBoundary = "batch_mockresponder"
+
+// BatchResp returns a multipart/mixed batch response as served by batch APIs
+// (e.g. Google-style batch requests or OData $batch).  Every inner response is
+// encoded as an application/http part holding the complete HTTP response with
+// its status code, headers and body, the part for the inner response i carries
+// the Content-ID "response-<i+1>".  Only Code, Header and Data of the inner
+// responses are used.
+func BatchResp(parts MockRespList) MockResp {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	// the boundary is valid, writing to a buffer can't fail
+	w.SetBoundary(batchBoundary)
+	for i, part := range parts {
+		code := part.Code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		pw, _ := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type": []string{"application/http"},
+			"Content-Id":   []string{fmt.Sprintf("response-%d", i+1)},
+		})
+		inner := &http.Response{
+			StatusCode:    code,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        part.Header,
+			Body:          io.NopCloser(bytes.NewReader(part.Data)),
+			ContentLength: int64(len(part.Data)),
+		}
+		inner.Write(pw)
+	}
+	w.Close()
+	return MockResp{
+		Data:   buf.Bytes(),
+		Header: http.Header{"Content-Type": []string{"multipart/mixed; boundary=" + batchBoundary}},
+	}
+}
+
+// NoContentResp returns a 204 No Content response as answered to successful
+// DELETE or PUT requests, the response has no body and a zero content length.
+func NoContentResp() MockResp {
+	return MockResp{Code: http.StatusNoContent}
+}
+
+// bodyAllowed returns false for status codes which must not carry a body.
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// exactURL returns a URL pattern which matches exactly the given URL.
+func exactURL(url string) string {
+	return "^" + regexp.QuoteMeta(sanitizeURL(url)) + "$"
+}
+
+// FromResponse snapshots the status, headers and body of the response into a
+// mocked response.  If the response has a request, the URL of the mocked
+// response matches exactly the URL of that request.  The body of resp is
+// consumed and replaced with an equivalent body so that it can still be read
+// by the caller.  If reading the body fails, the data read so far and the error
+// are returned, the error is set as BodyErr of the mocked response.
+func FromResponse(resp *http.Response) (MockResp, error) {
+	if resp == nil {
+		return MockResp{}, errors.New("no response")
+	}
+	stub := MockResp{
+		Code:   resp.StatusCode,
+		Header: resp.Header.Clone(),
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		stub.URL = exactURL(resp.Request.URL.String())
+	}
+	ctx := context.Background()
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+	}
+	var err error
+	if resp.Body != nil {
+		stub.Data, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		stub.BodyErr = err
+		resp.Body = newMockBody(ctx, bytes.NewReader(stub.Data), stub)
+	}
+	return stub, err
+}
+
+// Kind is the category of a mocked response.
+type Kind int
+
+const (
+	// KindSuccess is a response with a 1xx, 2xx or 3xx status code.
+	KindSuccess Kind = iota
+	// KindProtocol is a response with a 4xx or 5xx status code.
+	KindProtocol
+	// KindTransport is an error returned from Do().
+	KindTransport
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindProtocol:
+		return "protocol error"
+	case KindTransport:
+		return "transport error"
+	}
+	return "success"
+}
+
+// Kind returns the category of the mocked response.
+func (mr MockResp) Kind() Kind {
+	switch {
+	case mr.Err != nil:
+		return KindTransport
+	case mr.Code >= http.StatusBadRequest:
+		return KindProtocol
+	}
+	return KindSuccess
+}
+
+// TransportError returns a response which fails the request with err, as a
+// failing connection would.  No response is returned from Do() in this case.
+func TransportError(err error) MockResp {
+	return MockResp{Err: err}
+}
+
+// ProtocolError returns a response with the given status code and body.  The
+// request succeeds on the transport level but the server reports an error.  It
+// panics if the code is not a 4xx or 5xx status code.
+func ProtocolError(code int, data []byte) MockResp {
+	if code < http.StatusBadRequest || code > 599 {
+		panic(fmt.Sprintf("status code %d is not a protocol error", code))
+	}
+	return MockResp{Code: code, Data: data}
+}
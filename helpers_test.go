@@ -0,0 +1,197 @@
+package mockresponder
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVResp(t *testing.T) {
+	records := [][]string{
+		{"id", "name"},
+		{"1", "router, core"},
+		{"2", `switch "access"`},
+	}
+	mrClient, ctx := NewMockResponder()
+	csvResp := CSVResp(records)
+	csvResp.URL = "export$"
+	mrClient.SetData(MockRespList{csvResp, TSVResp(records)})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "bla://bla/export", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	got, err := csv.NewReader(resp.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, records, got)
+
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/tab-separated-values; charset=utf-8", resp.Header.Get("Content-Type"))
+	r := csv.NewReader(resp.Body)
+	r.Comma = '\t'
+	got, err = r.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, records, got)
+}
+
+func TestJSONResp(t *testing.T) {
+	type device struct {
+		ID   int      `json:"id"`
+		Name string   `json:"name"`
+		Tags []string `json:"tags,omitempty"`
+	}
+	want := []device{{ID: 1, Name: "core", Tags: []string{"a"}}, {ID: 2, Name: "edge"}}
+	mrClient, ctx := NewMockResponder()
+	r := JSONResp(want)
+	r.URL = "devices$"
+	mrClient.SetData(MockRespList{r})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/devices", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var got []device
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, want, got)
+
+	assert.Panics(t, func() { JSONResp(make(chan int)) })
+}
+
+func TestProblemResp(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		ProblemResp(http.StatusForbidden, "https://example.com/probs/out-of-credit",
+			"You do not have enough credit.", "Your current balance is 30, but that costs 50."),
+		ProblemResp(http.StatusNotFound, "", "Not Found", ""),
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/account", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+	var p Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+	assert.Equal(t, Problem{
+		Type:   "https://example.com/probs/out-of-credit",
+		Title:  "You do not have enough credit.",
+		Status: http.StatusForbidden,
+		Detail: "Your current balance is 30, but that costs 50.",
+	}, p)
+
+	assert.JSONEq(t, `{"title": "Not Found", "status": 404}`, string(mrClient.GetData()[1].Data))
+}
+
+func TestBatchResp(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		BatchResp(MockRespList{
+			{Data: []byte(`{"id": 1}`), Header: http.Header{"Content-Type": []string{"application/json"}}},
+			ProtocolError(http.StatusNotFound, nil),
+		}),
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/batch", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	r := multipart.NewReader(resp.Body, params["boundary"])
+	var codes []int
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, "application/http", part.Header.Get("Content-Type"))
+		assert.Equal(t, fmt.Sprintf("response-%d", len(codes)+1), part.Header.Get("Content-Id"))
+		inner, err := http.ReadResponse(bufio.NewReader(part), nil)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(inner.Body)
+		if inner.StatusCode == http.StatusOK {
+			assert.Equal(t, `{"id": 1}`, string(body))
+			assert.Equal(t, "application/json", inner.Header.Get("Content-Type"))
+		}
+		codes = append(codes, inner.StatusCode)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusNotFound}, codes)
+}
+
+func TestNoContentResp(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{NoContentResp(), MockResp{Data: []byte(`OK`)}})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, "/thing", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, http.NoBody, resp.Body)
+	assert.Zero(t, resp.ContentLength)
+
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int64(2), resp.ContentLength)
+}
+
+func TestFromResponse(t *testing.T) {
+	_, err := FromResponse(nil)
+	assert.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	rec.WriteHeader(http.StatusCreated)
+	rec.WriteString(`{"id": 1}`)
+	resp := rec.Result()
+	resp.Request, _ = http.NewRequest(http.MethodPost, "http://bla/devices?x=1", nil)
+
+	stub, err := FromResponse(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, stub.Code)
+	assert.Equal(t, "application/json", stub.Header.Get("Content-Type"))
+	assert.Equal(t, []byte(`{"id": 1}`), stub.Data)
+	assert.Equal(t, `^http://bla/devices\?x=1$`, stub.URL)
+
+	// the body can still be read
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, stub.Data, body)
+
+	bodyErr := errors.New("ka-boom")
+	resp = &http.Response{Body: newMockBody(context.TODO(), strings.NewReader("part"), MockResp{BodyErr: bodyErr})}
+	stub, err = FromResponse(resp)
+	assert.ErrorIs(t, err, bodyErr)
+	assert.Equal(t, bodyErr, stub.BodyErr)
+	assert.Equal(t, []byte("part"), stub.Data)
+	assert.Empty(t, stub.URL)
+}
+
+func TestMockResp_Kind(t *testing.T) {
+	assert.Equal(t, KindTransport, TransportError(errors.New("ka-boom")).Kind())
+	assert.Equal(t, KindProtocol, ProtocolError(http.StatusServiceUnavailable, nil).Kind())
+	assert.Equal(t, KindSuccess, MockResp{}.Kind())
+	assert.Equal(t, "protocol error", KindProtocol.String())
+	assert.Panics(t, func() { ProtocolError(http.StatusOK, nil) })
+}
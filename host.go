@@ -0,0 +1,63 @@
+package mockresponder
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SetHostCheck enables or disables the check of the Host header.  When
+// enabled, a violation is recorded for every request whose Host header does
+// not match the expected host or, if expected is empty, the host of the
+// request URL.  In server mode, where the URL of a request has no host, it's
+// the address the server listens on instead.  This catches proxy and virtual
+// host misconfiguration in clients, see Violations().
+func (m *MockResponder) SetHostCheck(enabled bool, expected string) {
+	m.mu.Lock()
+	m.hostCheck = enabled
+	m.expectedHost = expected
+	m.mu.Unlock()
+}
+
+// requestHost returns the host the request is sent to, like http.Client an
+// empty Host uses the host of the URL.
+func requestHost(req *http.Request) string {
+	if len(req.Host) > 0 {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// matchHost returns true if host matches the wanted host.  A wanted host
+// without a port matches any port.
+func matchHost(host, want string) bool {
+	if strings.EqualFold(host, want) {
+		return true
+	}
+	u := url.URL{Host: want}
+	if len(u.Port()) > 0 {
+		return false
+	}
+	u.Host = host
+	return strings.EqualFold(u.Hostname(), want)
+}
+
+// checkHost records a violation if the Host header of the request is not the
+// expected one.
+func (m *MockResponder) checkHost(req *http.Request) {
+	if !m.hostCheck {
+		return
+	}
+	host := requestHost(req)
+	want := m.expectedHost
+	if len(want) == 0 {
+		want = req.URL.Host
+		if addr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+			want = addr.String()
+		}
+	}
+	if !strings.EqualFold(host, want) {
+		m.violate("request %s %s has Host %q, expected %q", req.Method, sanitizeURL(req.URL.String()), host, want)
+	}
+}
@@ -0,0 +1,61 @@
+package mockresponder
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetHostCheck(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}, MockResp{}, MockResp{}})
+
+	get := func(host string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://api.example.com/bla", nil)
+		req.Host = host
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	get("other.example.com")
+	assert.Empty(t, mrClient.Violations())
+
+	mrClient.SetHostCheck(true, "")
+	get("")
+	get("other.example.com")
+	assert.Equal(t, []string{
+		`request GET http://api.example.com/bla has Host "other.example.com", expected "api.example.com"`,
+	}, mrClient.Violations())
+
+	mrClient.ResetAll()
+	mrClient.SetHostCheck(true, "other.example.com")
+	get("Other.Example.com")
+	assert.Empty(t, mrClient.Violations())
+}
+
+func TestMockResponder_SetHostCheckServer(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}})
+	mrClient.SetHostCheck(true, "")
+	s := mrClient.Server()
+	defer s.Close()
+
+	get := func(host string) {
+		req, _ := http.NewRequest(http.MethodGet, s.URL+"/bla", nil)
+		req.Host = host
+		resp, err := s.Client().Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	get("")
+	assert.Empty(t, mrClient.Violations())
+
+	// the Host header is checked against the address of the server
+	get("api.example.com")
+	assert.Equal(t, []string{
+		fmt.Sprintf(`request GET http://api.example.com/bla has Host "api.example.com", expected %q`, strings.TrimPrefix(s.URL, "http://")),
+	}, mrClient.Violations())
+}
@@ -0,0 +1,95 @@
+package mockresponder
+
+import "fmt"
+
+// HTTP2ErrCode is an HTTP/2 error code as defined in RFC 7540, section 7.
+type HTTP2ErrCode uint32
+
+// HTTP/2 error codes
+const (
+	HTTP2NoError            HTTP2ErrCode = 0x0
+	HTTP2ProtocolError      HTTP2ErrCode = 0x1
+	HTTP2InternalError      HTTP2ErrCode = 0x2
+	HTTP2FlowControlError   HTTP2ErrCode = 0x3
+	HTTP2SettingsTimeout    HTTP2ErrCode = 0x4
+	HTTP2StreamClosed       HTTP2ErrCode = 0x5
+	HTTP2FrameSizeError     HTTP2ErrCode = 0x6
+	HTTP2RefusedStream      HTTP2ErrCode = 0x7
+	HTTP2Cancel             HTTP2ErrCode = 0x8
+	HTTP2CompressionError   HTTP2ErrCode = 0x9
+	HTTP2ConnectError       HTTP2ErrCode = 0xa
+	HTTP2EnhanceYourCalm    HTTP2ErrCode = 0xb
+	HTTP2InadequateSecurity HTTP2ErrCode = 0xc
+	HTTP2HTTP11Required     HTTP2ErrCode = 0xd
+)
+
+var http2ErrCodeName = map[HTTP2ErrCode]string{
+	HTTP2NoError:            "NO_ERROR",
+	HTTP2ProtocolError:      "PROTOCOL_ERROR",
+	HTTP2InternalError:      "INTERNAL_ERROR",
+	HTTP2FlowControlError:   "FLOW_CONTROL_ERROR",
+	HTTP2SettingsTimeout:    "SETTINGS_TIMEOUT",
+	HTTP2StreamClosed:       "STREAM_CLOSED",
+	HTTP2FrameSizeError:     "FRAME_SIZE_ERROR",
+	HTTP2RefusedStream:      "REFUSED_STREAM",
+	HTTP2Cancel:             "CANCEL",
+	HTTP2CompressionError:   "COMPRESSION_ERROR",
+	HTTP2ConnectError:       "CONNECT_ERROR",
+	HTTP2EnhanceYourCalm:    "ENHANCE_YOUR_CALM",
+	HTTP2InadequateSecurity: "INADEQUATE_SECURITY",
+	HTTP2HTTP11Required:     "HTTP_1_1_REQUIRED",
+}
+
+func (e HTTP2ErrCode) String() string {
+	if s, ok := http2ErrCodeName[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown error code 0x%x", uint32(e))
+}
+
+// GoAwayError mirrors the error returned by Go's HTTP/2 client when the
+// server sent a GOAWAY frame and closed the connection.  Fields and message
+// match those of golang.org/x/net/http2 and its copy bundled in net/http, so
+// retry logic which inspects the error message can be tested.  Type based
+// checks need to use this type.
+type GoAwayError struct {
+	LastStreamID uint32
+	ErrCode      HTTP2ErrCode
+	DebugData    string
+}
+
+func (e GoAwayError) Error() string {
+	return fmt.Sprintf("http2: server sent GOAWAY and closed the connection; LastStreamID=%v, ErrCode=%v, debug=%q",
+		e.LastStreamID, e.ErrCode, e.DebugData)
+}
+
+// StreamError mirrors the error returned by Go's HTTP/2 client when the
+// server reset the stream of a request, see GoAwayError.
+type StreamError struct {
+	StreamID uint32
+	Code     HTTP2ErrCode
+	Cause    error
+}
+
+func (e StreamError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("stream error: stream ID %d; %v; %v", e.StreamID, e.Code, e.Cause)
+	}
+	return fmt.Sprintf("stream error: stream ID %d; %v", e.StreamID, e.Code)
+}
+
+func (e StreamError) Unwrap() error {
+	return e.Cause
+}
+
+// GoAway returns a transport error response simulating a GOAWAY frame sent by
+// the server, see GoAwayError.
+func GoAway(lastStreamID uint32, code HTTP2ErrCode, debug string) MockResp {
+	return TransportError(GoAwayError{LastStreamID: lastStreamID, ErrCode: code, DebugData: debug})
+}
+
+// StreamReset returns a transport error response simulating a reset of the
+// request stream by the server, see StreamError.
+func StreamReset(streamID uint32, code HTTP2ErrCode) MockResp {
+	return TransportError(StreamError{StreamID: streamID, Code: code})
+}
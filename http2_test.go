@@ -0,0 +1,35 @@
+package mockresponder
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP2Errors(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		GoAway(1, HTTP2NoError, ""),
+		StreamReset(3, HTTP2RefusedStream),
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	_, err := mrClient.Do(req)
+	var goAway GoAwayError
+	assert.True(t, errors.As(err, &goAway))
+	assert.EqualError(t, err, `http2: server sent GOAWAY and closed the connection; LastStreamID=1, ErrCode=NO_ERROR, debug=""`)
+
+	_, err = mrClient.Do(req)
+	var streamErr StreamError
+	assert.True(t, errors.As(err, &streamErr))
+	assert.Equal(t, HTTP2RefusedStream, streamErr.Code)
+	assert.EqualError(t, err, "stream error: stream ID 3; REFUSED_STREAM")
+
+	cause := errors.New("ka-boom")
+	err = StreamError{StreamID: 5, Code: HTTP2InternalError, Cause: cause}
+	assert.ErrorIs(t, err, cause)
+	assert.EqualError(t, err, "stream error: stream ID 5; INTERNAL_ERROR; ka-boom")
+	assert.Equal(t, "unknown error code 0x42", HTTP2ErrCode(0x42).String())
+}
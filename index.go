@@ -0,0 +1,124 @@
+package mockresponder
+
+import (
+	"net/http"
+	"net/url"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// stubIndex groups the responses by method, host and the literal prefix of
+// their URL pattern so that a request is only matched against the responses
+// which can serve it.  Responses without a method or host are in the wildcard
+// groups which are matched against every request, likewise responses whose
+// URL pattern isn't anchored or is a glob are scanned for every request.
+type stubIndex struct {
+	data    MockRespList
+	rank    []int
+	groups  map[indexKey][]int
+	lengths []int
+}
+
+// indexKey is the upper-cased method, the lower-cased host name without port
+// and the URL prefix of a group, empty for any method, host or URL.
+type indexKey struct {
+	method string
+	host   string
+	prefix string
+}
+
+// hostKey returns the lower-cased host name of the host, without port.
+func hostKey(host string) string {
+	u := url.URL{Host: host}
+	return strings.ToLower(u.Hostname())
+}
+
+// stubIndex returns the index of the current responses, it's rebuilt whenever
+// the responses are replaced.
+func (m *MockResponder) stubIndex() *stubIndex {
+	idx := m.index
+	if idx != nil && len(idx.data) == len(m.mockData) &&
+		(len(m.mockData) == 0 || &idx.data[0] == &m.mockData[0]) {
+		return idx
+	}
+	idx = &stubIndex{
+		data:    m.mockData,
+		rank:    make([]int, len(m.mockData)),
+		groups:  make(map[indexKey][]int),
+		lengths: []int{0},
+	}
+	lengths := map[int]bool{0: true}
+	for r, i := range m.byPriority() {
+		data := &m.mockData[i]
+		idx.rank[i] = r
+		key := indexKey{method: strings.ToUpper(data.Method), host: hostKey(data.Host)}
+		if !data.Glob {
+			key.prefix = urlPrefix(data.URL)
+		}
+		if !lengths[len(key.prefix)] {
+			lengths[len(key.prefix)] = true
+			idx.lengths = append(idx.lengths, len(key.prefix))
+		}
+		idx.groups[key] = append(idx.groups[key], i)
+	}
+	sort.Ints(idx.lengths)
+	m.index = idx
+	return idx
+}
+
+// urlPrefix returns the literal prefix of the URLs matched by a URL pattern
+// which is anchored at the beginning, e.g. "/api/devices/" for
+// "^/api/devices/[0-9]+$", empty if there's none.
+func urlPrefix(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil || re.Op != syntax.OpConcat || re.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+	var prefix []rune
+	for _, sub := range re.Sub[1:] {
+		if sub.Op != syntax.OpLiteral || sub.Flags&syntax.FoldCase != 0 {
+			break
+		}
+		prefix = append(prefix, sub.Rune...)
+	}
+	return string(prefix)
+}
+
+// candidates returns the indices of the responses which can serve the request
+// based on its method, host and URL, ordered by descending priority.  As the
+// prefixes are case-sensitive, the URL is disregarded if the case of URLs is
+// ignored, see SetIgnoreURLCase.
+func (m *MockResponder) candidates(req *http.Request) []int {
+	idx := m.stubIndex()
+	method, host := strings.ToUpper(req.Method), hostKey(requestHost(req))
+	keys := []indexKey{{method: method}, {}}
+	if len(host) > 0 {
+		keys = append(keys, indexKey{method: method, host: host}, indexKey{host: host})
+	}
+	var list []int
+	if m.ignoreURLCase {
+		for key, group := range idx.groups {
+			for _, k := range keys {
+				if key.method == k.method && key.host == k.host {
+					list = append(list, group...)
+				}
+			}
+		}
+	} else {
+		target := m.urlTarget(req)
+		for _, key := range keys {
+			for _, n := range idx.lengths {
+				if n > len(target) {
+					break
+				}
+				key.prefix = target[:n]
+				list = append(list, idx.groups[key]...)
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return idx.rank[list[i]] < idx.rank[list[j]]
+	})
+	return list
+}
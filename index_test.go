@@ -0,0 +1,99 @@
+package mockresponder
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_candidates(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Method: "get", Data: []byte(`get`)},
+		MockResp{Method: "POST", Host: "api.example.com", Data: []byte(`post api`)},
+		MockResp{Host: "API.example.com:8443", Data: []byte(`api`)},
+		MockResp{Data: []byte(`any`), Priority: 1},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://bla/devices", nil)
+	assert.Equal(t, []int{3, 0}, mrClient.candidates(req))
+	req, _ = http.NewRequest(http.MethodPost, "https://api.example.com:8443/devices", nil)
+	assert.Equal(t, []int{3, 1, 2}, mrClient.candidates(req))
+	req, _ = http.NewRequest(http.MethodPost, "/devices", nil)
+	assert.Equal(t, []int{3}, mrClient.candidates(req))
+
+	do := func(method, url string) string {
+		req, _ := http.NewRequestWithContext(ctx, method, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	assert.Equal(t, "any", do(http.MethodPut, "https://api.example.com:8443/devices"))
+	assert.Equal(t, "post api", do(http.MethodPost, "https://api.example.com:8443/devices"))
+	assert.Equal(t, "api", do(http.MethodPost, "https://api.example.com:8443/devices"))
+	assert.Equal(t, "get", do(http.MethodGet, "https://api.example.com:8443/devices"))
+	assert.True(t, mrClient.Empty())
+}
+
+func TestMockResponder_candidatesPrefix(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "^/devices/[0-9]+$"},
+		MockResp{URL: "^/devices$"},
+		MockResp{URL: "^/users"},
+		MockResp{URL: "/devices"},
+		MockResp{URL: "/devices/*", Glob: true},
+	})
+	mrClient.SetMatchPathOnly(true)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://bla/devices/1", nil)
+	assert.Equal(t, []int{0, 1, 3, 4}, mrClient.candidates(req))
+	req, _ = http.NewRequest(http.MethodGet, "http://bla/users/1", nil)
+	assert.Equal(t, []int{2, 3, 4}, mrClient.candidates(req))
+
+	mrClient.SetIgnoreURLCase(true)
+	req, _ = http.NewRequest(http.MethodGet, "http://bla/USERS/1", nil)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, mrClient.candidates(req))
+}
+
+func Test_urlPrefix(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"^/api/devices/[0-9]+$": "/api/devices/",
+		"^/api/devices$":        "/api/devices",
+		"^/ab*":                 "/a",
+		"^(?i)/api":             "",
+		"/api":                  "",
+		"^/a|^/b":               "",
+		"":                      "",
+		"^(/api":                "",
+	} {
+		assert.Equal(t, want, urlPrefix(pattern), pattern)
+	}
+}
+
+func BenchmarkMockResponder_match(b *testing.B) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetLogger(func(string, ...any) {})
+	var data MockRespList
+	for i := 0; i < 5000; i++ {
+		data = append(data, MockResp{
+			Method: []string{http.MethodGet, http.MethodPost, http.MethodPut}[i%3],
+			Host:   fmt.Sprintf("svc%d.example.com", i%50),
+			URL:    fmt.Sprintf("/items/%d$", i),
+		})
+	}
+	mrClient.SetData(data)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://svc49.example.com/items/4999", nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, found, _ := mrClient.match(req)
+		if !found {
+			b.Fatal("no match")
+		}
+	}
+}
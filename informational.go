@@ -0,0 +1,58 @@
+package mockresponder
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+)
+
+// Informational is a 1xx informational response which is sent ahead of the
+// final response, see MockResp.Informational.
+type Informational struct {
+	Code   int
+	Header http.Header
+}
+
+// EarlyHints returns a 103 Early Hints response with a Link header for every
+// given link, e.g. `</style.css>; rel=preload; as=style`.
+func EarlyHints(links ...string) Informational {
+	return Informational{
+		Code:   http.StatusEarlyHints,
+		Header: http.Header{"Link": links},
+	}
+}
+
+// sendInformational delivers the informational responses to the
+// Got1xxResponse hook of the client trace of the request, if any.  In server
+// mode, the hook writes them to the connection.
+func sendInformational(req *http.Request, informational []Informational) error {
+	trace := httptrace.ContextClientTrace(req.Context())
+	if trace == nil || trace.Got1xxResponse == nil {
+		return nil
+	}
+	for _, i := range informational {
+		if err := trace.Got1xxResponse(i.Code, textproto.MIMEHeader(i.Header.Clone())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withInformational returns the request with a client trace which writes 1xx
+// informational responses to w.
+func withInformational(w http.ResponseWriter, req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(code)
+			// the headers of the final response are set separately
+			for k := range header {
+				w.Header().Del(k)
+			}
+			return nil
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,52 @@
+package mockresponder
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_Informational(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	stub := MockResp{
+		Informational: []Informational{EarlyHints("</style.css>; rel=preload; as=style")},
+		Header:        http.Header{"X-Final": []string{"yes"}},
+	}
+	mrClient.SetData(MockRespList{stub, stub})
+
+	var codes []int
+	var links []string
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			codes = append(codes, code)
+			links = append(links, header.Get("Link"))
+			assert.Empty(t, header.Get("X-Final"))
+			return nil
+		},
+	}
+
+	req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, "/page", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	s := mrClient.Server()
+	defer s.Close()
+	req, _ = http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, s.URL+"/page", nil)
+	resp, err = s.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Link"))
+	assert.Equal(t, "yes", resp.Header.Get("X-Final"))
+
+	assert.Equal(t, []int{http.StatusEarlyHints, http.StatusEarlyHints}, codes)
+	assert.Equal(t, []string{"</style.css>; rel=preload; as=style", "</style.css>; rel=preload; as=style"}, links)
+
+	assert.EqualError(t, MockResp{Informational: []Informational{{Code: http.StatusOK}}}.Validate(),
+		"status code 200 is not an informational response")
+}
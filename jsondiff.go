@@ -0,0 +1,138 @@
+package mockresponder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// jsonDiff returns the structural differences between the JSON documents got
+// and want, like missing keys and changed values, each prefixed with the path
+// of the value (e.g. "$.items[2].name").  It returns false if either document
+// isn't valid JSON.
+func jsonDiff(got, want []byte) ([]string, bool) {
+	g, ok := decodeJSON(got)
+	if !ok {
+		return nil, false
+	}
+	w, ok := decodeJSON(want)
+	if !ok {
+		return nil, false
+	}
+	var diffs []string
+	diffValues("$", g, w, &diffs)
+	return diffs, true
+}
+
+// marshalJSON returns v as JSON, values which are JSON already are returned as
+// is.
+func marshalJSON(v any) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case json.RawMessage:
+		return t, nil
+	}
+	return json.Marshal(v)
+}
+
+// mismatchJSON compares the JSON body of the request with the expected value.
+func mismatchJSON(want any, req *http.Request) string {
+	data, err := marshalJSON(want)
+	if err != nil {
+		return fmt.Sprintf("expected JSON body is invalid: %s", err)
+	}
+	diffs, ok := jsonDiff(payload(req), data)
+	if !ok {
+		return "body is not JSON"
+	}
+	if len(diffs) > 0 {
+		return "JSON body differs: " + strings.Join(diffs, "; ")
+	}
+	return ""
+}
+
+// decodeJSON decodes a single JSON document, keeping numbers verbatim.
+func decodeJSON(data []byte) (any, bool) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, false
+	}
+	// trailing data is not a single document
+	if d.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// diffValues appends the differences between the decoded JSON values got and
+// want at the given path to diffs.
+func diffValues(path string, got, want any, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			gv, inGot := g[k]
+			wv, inWant := w[k]
+			p := fmt.Sprintf("%s.%s", path, k)
+			switch {
+			case !inGot:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, want %s", p, jsonString(wv)))
+			case !inWant:
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", p, jsonString(gv)))
+			default:
+				diffValues(p, gv, wv, diffs)
+			}
+		}
+		return
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			break
+		}
+		if len(g) != len(w) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %d elements, want %d", path, len(g), len(w)))
+		}
+		for i := 0; i < len(g) && i < len(w); i++ {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), g[i], w[i], diffs)
+		}
+		return
+	case json.Number:
+		// numbers with different representations like 1 and 1.0 are equal
+		if g, ok := got.(json.Number); ok {
+			gf, gerr := g.Float64()
+			wf, werr := w.Float64()
+			if gerr == nil && werr == nil && gf == wf {
+				return
+			}
+		}
+	}
+	if gs, ws := jsonString(got), jsonString(want); gs != ws {
+		*diffs = append(*diffs, fmt.Sprintf("%s: got %s, want %s", path, gs, ws))
+	}
+}
+
+// jsonString returns the compact JSON representation of a decoded value.
+func jsonString(v any) string {
+	// decoded values can always be marshalled
+	data, _ := json.Marshal(v)
+	return string(data)
+}
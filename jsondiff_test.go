@@ -0,0 +1,64 @@
+package mockresponder
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_jsonDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+		diff []string
+		ok   bool
+	}{
+		{"equal", `{"a": 1, "b": [1, 2]}`, `{"b":[1,2],"a":1}`, nil, true},
+		{"changed", `{"a": 1, "b": {"c": "x"}}`, `{"a": 2, "b": {"c": "y"}}`, []string{
+			`$.a: got 1, want 2`,
+			`$.b.c: got "x", want "y"`,
+		}, true},
+		{"keys", `{"a": 1, "c": true}`, `{"a": 1, "b": null}`, []string{
+			`$.b: missing, want null`,
+			`$.c: unexpected true`,
+		}, true},
+		{"array", `[{"id": 1}, {"id": 3}, {"id": 4}]`, `[{"id": 1}, {"id": 2}]`, []string{
+			`$: got 3 elements, want 2`,
+			`$[1].id: got 3, want 2`,
+		}, true},
+		{"type", `{"a": [1]}`, `{"a": {"b": 1}}`, []string{`$.a: got [1], want {"b":1}`}, true},
+		{"numbers", `[1.0, 2]`, `[1, 2e0]`, nil, true},
+		{"number", `1.5`, `1`, []string{`$: got 1.5, want 1`}, true},
+		{"invalid", `{"a": 1`, `{}`, nil, false},
+		{"trailing", `{} {}`, `{}`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, ok := jsonDiff([]byte(tt.got), []byte(tt.want))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.diff, diff)
+		})
+	}
+}
+
+func TestMockResponder_outOfDataDiff(t *testing.T) {
+	var lines []string
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	mrClient.SetFailurePolicy(FailError)
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/devices$", MatchBody: []byte(`{"name": "r1"}`), Data: []byte(`device r1`)},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/devices", strings.NewReader(`{"name": "r2"}`))
+	_, err := mrClient.Do(req)
+	assert.ErrorIs(t, err, ErrOutOfData)
+	assert.Contains(t, lines, `0 ("/devices$"): body differs: $.name: got "r2", want "r1"`)
+	assert.NotContains(t, strings.Join(lines, "\n"), "device r1")
+}
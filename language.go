@@ -0,0 +1,61 @@
+package mockresponder
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// primary returns the primary subtag of a language tag, e.g. "de" for "de-CH".
+func primary(tag string) string {
+	p, _, _ := strings.Cut(tag, "-")
+	return p
+}
+
+// negotiateLanguage returns the variant which best matches the Accept-Language
+// header.  Exact matches are preferred over matches of the primary subtag,
+// of several regional variants of a language the first one in lexical order
+// is used, e.g. "en-GB" rather than "en-US" for "en".
+func negotiateLanguage(accept string, variants map[string][]byte) (string, bool) {
+	lower := make(map[string]string, len(variants))
+	tags := make([]string, 0, len(variants))
+	for tag := range variants {
+		lower[strings.ToLower(tag)] = tag
+		tags = append(tags, strings.ToLower(tag))
+	}
+	sort.Strings(tags)
+	for _, a := range parseAccept(accept) {
+		if a.q <= 0 || a.value == "*" {
+			continue
+		}
+		if tag, ok := lower[a.value]; ok {
+			return tag, true
+		}
+		if tag, ok := lower[primary(a.value)]; ok {
+			return tag, true
+		}
+		for _, l := range tags {
+			if primary(l) == a.value {
+				return lower[l], true
+			}
+		}
+	}
+	return "", false
+}
+
+// selectLanguage returns the body of the variant for the language accepted by
+// the request and sets the accompanying headers.  Without an acceptable
+// variant, the LanguageFallback variant is used or, if that doesn't exist,
+// the Data of the response.
+func selectLanguage(req *http.Request, header http.Header, data MockResp) []byte {
+	header.Add("Vary", "Accept-Language")
+	tag, ok := negotiateLanguage(req.Header.Get("Accept-Language"), data.Languages)
+	if !ok {
+		if _, exists := data.Languages[data.LanguageFallback]; !exists {
+			return data.Data
+		}
+		tag = data.LanguageFallback
+	}
+	header.Set("Content-Language", tag)
+	return data.Languages[tag]
+}
@@ -0,0 +1,54 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_Languages(t *testing.T) {
+	variants := map[string][]byte{
+		"en":    []byte(`hello`),
+		"de":    []byte(`hallo`),
+		"fr-CA": []byte(`allo`),
+		"pt-PT": []byte(`olá`),
+		"pt-BR": []byte(`oi`),
+	}
+	tests := []struct {
+		name     string
+		accept   string
+		fallback string
+		want     string
+		language string
+	}{
+		{"exact", "de", "", "hallo", "de"},
+		{"quality", "de;q=0.5, en", "", "hello", "en"},
+		{"region", "de-CH, en;q=0.8", "", "hallo", "de"},
+		{"primary", "fr", "", "allo", "fr-CA"},
+		{"case", "FR-ca", "", "allo", "fr-CA"},
+		{"variants", "pt", "", "oi", "pt-BR"},
+		{"fallback", "it", "en", "hello", "en"},
+		{"wildcard", "*", "de", "hallo", "de"},
+		{"data", "it", "", "ciao", ""},
+		{"missingfallback", "", "es", "ciao", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrClient, ctx := NewMockResponder()
+			mrClient.SetData(MockRespList{
+				MockResp{Data: []byte(`ciao`), Languages: variants, LanguageFallback: tt.fallback},
+			})
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/greeting", nil)
+			req.Header.Set("Accept-Language", tt.accept)
+			resp, err := mrClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.want, string(body))
+			assert.Equal(t, tt.language, resp.Header.Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", resp.Header.Get("Vary"))
+		})
+	}
+}
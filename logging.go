@@ -0,0 +1,22 @@
+package mockresponder
+
+import "log"
+
+// SetLogger sets the function which is used for logging by the responder.  A
+// nil logger logs via the standard logger of the log package.
+func (m *MockResponder) SetLogger(logger func(format string, args ...any)) {
+	m.logMu.Lock()
+	m.logger = logger
+	m.logMu.Unlock()
+}
+
+func (m *MockResponder) logf(format string, args ...any) {
+	m.logMu.Lock()
+	logger := m.logger
+	m.logMu.Unlock()
+	if logger == nil {
+		log.Printf(format, args...)
+		return
+	}
+	logger(format, args...)
+}
@@ -0,0 +1,37 @@
+package mockresponder
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetLogger(t *testing.T) {
+	var lines []string
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	mrClient.SetData(MockRespList{MockResp{Code: http.StatusNoContent}})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "bla://bla/ok", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{
+		"mock request url GET bla://bla/ok",
+		"GET <bla://bla/ok>, 204: /204/<nil>/true\n",
+	}, lines)
+}
+
+func TestNewMockResponderT(t *testing.T) {
+	var mrClient *MockResponder
+	t.Run("sub", func(t *testing.T) {
+		mrClient, _ = NewMockResponderT(t)
+		mrClient.logf("attached to %s", t.Name())
+	})
+	// the test has finished, logging must not panic
+	assert.NotPanics(t, func() { mrClient.logf("silenced") })
+}
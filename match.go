@@ -0,0 +1,379 @@
+package mockresponder
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// match returns the index of the first unserved response which matches the
+// request, responses with a higher priority are considered first.  With a
+// match strategy, all unserved responses are considered and the strategy
+// selects among the matching ones, see SetMatchStrategy.  Only the
+// responses whose method and host can match the request are considered, see
+// stubIndex.  If a pattern of an unserved response which is considered before
+// a match is found is invalid, the index of that response and the error are
+// returned.
+func (m *MockResponder) match(req *http.Request) (int, bool, error) {
+	// trailers are only available once the body was read
+	readBody(req)
+	var matches []Candidate
+	for _, idx := range m.candidates(req) {
+		if m.mockData[idx].served {
+			continue
+		}
+		if err := m.patternError(&m.mockData[idx]); err != nil {
+			return idx, false, err
+		}
+		if m.mismatch(&m.mockData[idx], req) != "" {
+			continue
+		}
+		if m.strategy == nil {
+			return idx, true, nil
+		}
+		matches = append(matches, Candidate{Index: idx, Resp: m.mockData[idx]})
+	}
+	if len(matches) == 0 {
+		return 0, false, nil
+	}
+	selected := 0
+	what := func() string { return "match strategy" }
+	if err := m.guard(what, func() { selected = m.strategy.Select(req, matches) }); err != nil {
+		// the candidates are ordered by priority
+		return matches[0].Index, true, nil
+	}
+	if selected < 0 || selected >= len(matches) {
+		m.violate("match strategy selected candidate %d of %d for request %s %s, serving the first",
+			selected, len(matches), req.Method, sanitizeURL(req.URL.String()))
+		return matches[0].Index, true, nil
+	}
+	return matches[selected].Index, true, nil
+}
+
+// Match returns the index of the mocked response which would be served for
+// the request without serving it, which allows helpers and debugging code to
+// ask what would answer a request.  If no response matches, ok is false and
+// reasons holds why each mocked response doesn't match, in the order the
+// responses were set.  A response with an invalid pattern is reported as the
+// only reason.  Only the mocked responses are considered, not the fallback,
+// outages or the stored resources.  Violations which occur while matching,
+// e.g. an out of range selection of the match strategy, are not recorded but
+// returned in reasons, also if a response matches.
+func (m *MockResponder) Match(req *http.Request) (index int, ok bool, reasons []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var violations []string
+	m.dryRun = &violations
+	defer func() { m.dryRun = nil }()
+	idx, found, err := m.match(req)
+	if err != nil {
+		return idx, false, append(violations, fmt.Sprintf("response %d (%q): %s", idx, m.mockData[idx].URL, err))
+	}
+	if found {
+		return idx, true, violations
+	}
+	reasons = violations
+	for idx := range m.mockData {
+		data := &m.mockData[idx]
+		reason := "already served"
+		if !data.served {
+			if err := m.patternError(data); err != nil {
+				reason = err.Error()
+			} else {
+				reason = m.mismatch(data, req)
+			}
+		}
+		reasons = append(reasons, fmt.Sprintf("response %d (%q): %s", idx, data.URL, reason))
+	}
+	return -1, false, reasons
+}
+
+// byPriority returns the indices of the responses ordered by descending
+// priority, responses with the same priority keep their order.
+func (m *MockResponder) byPriority() []int {
+	order := make([]int, len(m.mockData))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return m.mockData[order[i]].Priority > m.mockData[order[j]].Priority
+	})
+	return order
+}
+
+// SetMatchPathOnly sets whether the URL patterns of the responses are matched
+// against the path of the request only, ignoring the scheme, host and query.
+// By default, the patterns are matched against the complete URL.
+func (m *MockResponder) SetMatchPathOnly(pathOnly bool) {
+	m.mu.Lock()
+	m.matchPathOnly = pathOnly
+	m.mu.Unlock()
+}
+
+// SetIgnoreURLCase sets whether the URL patterns and globs of the responses
+// are matched regardless of case, e.g. "/api/Devices$" matches a request to
+// "/API/devices".  This keeps responses working with clients and servers which
+// canonicalize the case of paths differently.  By default, URLs are matched
+// case-sensitively.
+func (m *MockResponder) SetIgnoreURLCase(ignore bool) {
+	m.mu.Lock()
+	m.ignoreURLCase = ignore
+	m.mu.Unlock()
+}
+
+// mismatch returns the reason why the response doesn't match the request or an
+// empty string if it does.
+func (m *MockResponder) mismatch(data *MockResp, req *http.Request) string {
+	if len(data.URL) > 0 && data.Glob {
+		// the pattern was validated before
+		pattern, target := data.URL, req.URL.Path
+		if m.ignoreURLCase {
+			pattern, target = strings.ToLower(pattern), strings.ToLower(target)
+		}
+		if ok, _ := path.Match(pattern, target); !ok {
+			return fmt.Sprintf("path %q does not match glob %q", req.URL.Path, data.URL)
+		}
+	}
+	if len(data.URL) > 0 && !data.Glob {
+		if target := m.urlTarget(req); !m.matchURL(data.URL, target) {
+			return fmt.Sprintf("URL %q does not match %q", target, data.URL)
+		}
+	}
+	if len(data.PathTemplate) > 0 {
+		if !m.matchURL(templatePattern(data.PathTemplate), req.URL.Path) {
+			return fmt.Sprintf("path %q does not match template %q", req.URL.Path, data.PathTemplate)
+		}
+	}
+	if len(data.NotURL) > 0 {
+		if target := m.urlTarget(req); m.matchURL(data.NotURL, target) {
+			return fmt.Sprintf("URL %q matches excluded %q", target, data.NotURL)
+		}
+	}
+	if data.ValidFor > 0 && !m.now().Before(data.registered.Add(data.ValidFor)) {
+		return fmt.Sprintf("expired %s after registration", data.ValidFor)
+	}
+	if data.Matcher != nil {
+		var ok bool
+		matcher := data.Matcher
+		what := func() string { return "matcher of " + m.describe(data) }
+		if err := m.guard(what, func() { ok = matcher(req) }); err != nil {
+			return err.Error()
+		}
+		if !ok {
+			return "rejected by the matcher function"
+		}
+	}
+	if len(data.Method) > 0 && !strings.EqualFold(data.Method, req.Method) {
+		return fmt.Sprintf("method %q is not %q", req.Method, data.Method)
+	}
+	if len(data.Host) > 0 && !matchHost(requestHost(req), data.Host) {
+		return fmt.Sprintf("host %q is not %q", requestHost(req), data.Host)
+	}
+	if len(data.Scheme) > 0 && !strings.EqualFold(data.Scheme, req.URL.Scheme) {
+		return fmt.Sprintf("scheme %q is not %q", req.URL.Scheme, data.Scheme)
+	}
+	if len(data.Proto) > 0 && data.Proto != req.Proto {
+		return fmt.Sprintf("protocol %q is not %q", req.Proto, data.Proto)
+	}
+	if reason := m.matchValues("header", data.MatchHeaders, req.Header); reason != "" {
+		return reason
+	}
+	if reason := m.matchValues("trailer", data.MatchTrailers, req.Trailer); reason != "" {
+		return reason
+	}
+	if reason := m.matchCookies(data.MatchCookies, req); reason != "" {
+		return reason
+	}
+	if data.MatchBasicAuth != nil {
+		if reason := mismatchBasicAuth(data.MatchBasicAuth, req); reason != "" {
+			return reason
+		}
+	}
+	if len(data.MatchBearerToken) > 0 {
+		if reason := mismatchBearerToken(data.MatchBearerToken, req); reason != "" {
+			return reason
+		}
+	}
+	if len(data.MatchContentType) > 0 {
+		if reason := mismatchContentType(data.MatchContentType, req); reason != "" {
+			return reason
+		}
+	}
+	if len(data.BodyPattern) > 0 {
+		start := time.Now()
+		matched := m.mustCompile(data.BodyPattern).Match(payload(req))
+		m.checkMatchTime(data.BodyPattern, start)
+		if !matched {
+			return fmt.Sprintf("body does not match %q", data.BodyPattern)
+		}
+	}
+	if data.MatchBody != nil {
+		if reason := m.mismatchBody(data, req); reason != "" {
+			return reason
+		}
+	}
+	if data.MatchJSON != nil {
+		if reason := mismatchJSON(data.MatchJSON, req); reason != "" {
+			return reason
+		}
+	}
+	if len(data.MatchForm) > 0 {
+		if reason := mismatchForm(data.MatchForm, req); reason != "" {
+			return reason
+		}
+	}
+	if data.MatchXML != nil {
+		if reason := mismatchXML(data.MatchXML, req); reason != "" {
+			return reason
+		}
+	}
+	if data.MatchMultipart != nil {
+		if reason := m.mismatchMultipart(data.MatchMultipart, req); reason != "" {
+			return reason
+		}
+	}
+	if reason := mismatchSOAP(data, req); reason != "" {
+		return reason
+	}
+	return ""
+}
+
+// urlTarget returns the part of the request URL which URL patterns are matched
+// against, see SetMatchPathOnly.
+func (m *MockResponder) urlTarget(req *http.Request) string {
+	if m.matchPathOnly {
+		return req.URL.Path
+	}
+	return req.URL.String()
+}
+
+// matchURL reports whether the URL pattern matches the target, see
+// SetIgnoreURLCase.
+func (m *MockResponder) matchURL(pattern, target string) bool {
+	if m.ignoreURLCase {
+		pattern = "(?i)" + pattern
+	}
+	defer m.checkMatchTime(pattern, time.Now())
+	return m.mustCompile(pattern).MatchString(target)
+}
+
+// matchValues checks that every key in want is present in got with at least
+// one value matching the regular expression in want.
+func (m *MockResponder) matchValues(kind string, want map[string]string, got http.Header) string {
+	for key, pattern := range want {
+		values := got.Values(key)
+		if len(values) == 0 {
+			return fmt.Sprintf("%s %q is missing", kind, key)
+		}
+		found := false
+		for _, v := range values {
+			if m.mustCompile(pattern).MatchString(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%s %q %q does not match %q", kind, key, values, pattern)
+		}
+	}
+	return ""
+}
+
+// matchCookies checks that every cookie in want is present in the request
+// with a value matching the regular expression in want.
+func (m *MockResponder) matchCookies(want map[string]string, req *http.Request) string {
+	for name, pattern := range want {
+		cookie, err := req.Cookie(name)
+		if err != nil {
+			return fmt.Sprintf("cookie %q is missing", name)
+		}
+		if !m.mustCompile(pattern).MatchString(cookie.Value) {
+			return fmt.Sprintf("cookie %q %q does not match %q", name, cookie.Value, pattern)
+		}
+	}
+	return ""
+}
+
+// mismatchContentType checks that the request has the wanted media type and
+// carries the parameters of the wanted content type, e.g. a charset.
+func mismatchContentType(want string, req *http.Request) string {
+	contentType := req.Header.Get("Content-Type")
+	got, gotParams, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Sprintf("content type %q is not %q", contentType, want)
+	}
+	// the content type was validated before
+	wanted, wantParams, _ := mime.ParseMediaType(want)
+	if got != wanted {
+		return fmt.Sprintf("content type %q is not %q", contentType, want)
+	}
+	for k, v := range wantParams {
+		if g, ok := gotParams[k]; !ok || (g != v && !(k == "charset" && strings.EqualFold(g, v))) {
+			return fmt.Sprintf("content type %q is not %q", contentType, want)
+		}
+	}
+	return ""
+}
+
+// mismatchForm checks that the URL-encoded form body of the request holds all
+// values in want for every key in want.
+func mismatchForm(want url.Values, req *http.Request) string {
+	if !isForm(req) {
+		return "body is not a URL-encoded form"
+	}
+	got, err := url.ParseQuery(string(payload(req)))
+	if err != nil {
+		return fmt.Sprintf("invalid form body: %s", err)
+	}
+	for key, values := range want {
+		for _, v := range values {
+			if !hasValue(got[key], v) {
+				return fmt.Sprintf("form field %q %q does not hold %q", key, got[key], v)
+			}
+		}
+	}
+	return ""
+}
+
+// hasValue reports whether v is in values.
+func hasValue(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// compile returns the compiled regular expression, compiled expressions are
+// cached.
+func (m *MockResponder) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := m.regexps[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if m.regexps == nil {
+		m.regexps = make(map[string]*regexp.Regexp)
+	}
+	m.regexps[pattern] = re
+	return re, nil
+}
+
+// mustCompile is like compile but panics if the pattern is invalid.  Patterns
+// are validated before matching, see patternError.
+func (m *MockResponder) mustCompile(pattern string) *regexp.Regexp {
+	re, err := m.compile(pattern)
+	if err != nil {
+		panic("regex pattern issue")
+	}
+	return re
+}
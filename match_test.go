@@ -0,0 +1,446 @@
+package mockresponder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_mismatch(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	tests := []struct {
+		name  string
+		data  MockResp
+		url   string
+		proto string
+		want  string
+	}{
+		{"any", MockResp{}, "http://bla/ok", "HTTP/1.1", ""},
+		{"url", MockResp{URL: "ok$"}, "http://bla/no", "HTTP/1.1", `URL "http://bla/no" does not match "ok$"`},
+		{"method", MockResp{Method: "get"}, "http://bla/ok", "HTTP/1.1", ""},
+		{"delete", MockResp{Method: http.MethodDelete}, "http://bla/ok", "HTTP/1.1", `method "GET" is not "DELETE"`},
+		{"host", MockResp{Host: "BLA"}, "http://bla:8080/ok", "HTTP/1.1", ""},
+		{"port", MockResp{Host: "bla:8443"}, "http://bla:8080/ok", "HTTP/1.1", `host "bla:8080" is not "bla:8443"`},
+		{"other host", MockResp{Host: "auth.example.com"}, "http://api.example.com/ok", "HTTP/1.1", `host "api.example.com" is not "auth.example.com"`},
+		{"https", MockResp{Scheme: "https"}, "https://bla/ok", "HTTP/1.1", ""},
+		{"downgrade", MockResp{Scheme: "https"}, "http://bla/ok", "HTTP/1.1", `scheme "http" is not "https"`},
+		{"http2", MockResp{Proto: "HTTP/2.0"}, "https://bla/ok", "HTTP/2.0", ""},
+		{"http1", MockResp{Proto: "HTTP/2.0"}, "https://bla/ok", "HTTP/1.1", `protocol "HTTP/1.1" is not "HTTP/2.0"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			req.Proto = tt.proto
+			assert.Equal(t, tt.want, mrClient.mismatch(&tt.data, req))
+		})
+	}
+}
+
+func TestMockResponder_Match(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/a$", Data: []byte(`a`)},
+		MockResp{URL: "/b$", Method: http.MethodDelete},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/a", nil)
+	idx, ok, reasons := mrClient.Match(req)
+	assert.True(t, ok)
+	assert.Equal(t, 0, idx)
+	assert.Empty(t, reasons)
+
+	// the response wasn't consumed
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, []byte(`a`), body)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/b", nil)
+	idx, ok, reasons = mrClient.Match(req)
+	assert.False(t, ok)
+	assert.Equal(t, -1, idx)
+	assert.Equal(t, []string{
+		`response 0 ("/a$"): already served`,
+		`response 1 ("/b$"): method "GET" is not "DELETE"`,
+	}, reasons)
+
+	mrClient.SetData(MockRespList{MockResp{URL: "("}})
+	idx, ok, reasons = mrClient.Match(req)
+	assert.False(t, ok)
+	assert.Equal(t, 0, idx)
+	assert.Len(t, reasons, 1)
+	assert.Contains(t, reasons[0], `response 0 ("("): `)
+}
+
+func TestMockResponder_MatchViolations(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/devices", Data: []byte(`first`)},
+		MockResp{URL: "/devices", Data: []byte(`second`)},
+	})
+	mrClient.SetMatchStrategy(MatchStrategyFunc(func(*http.Request, []Candidate) int {
+		return 2
+	}))
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/devices", nil)
+	idx, ok, reasons := mrClient.Match(req)
+	assert.True(t, ok)
+	assert.Equal(t, 0, idx)
+	assert.Equal(t, []string{
+		"match strategy selected candidate 2 of 2 for request GET http://bla/devices, serving the first",
+	}, reasons)
+	assert.Empty(t, mrClient.Violations())
+}
+
+func TestMockResponder_MatchTrailers(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`OK`), MatchTrailers: map[string]string{"X-Checksum": "^[0-9a-f]+$"}},
+	})
+	s := mrClient.Server()
+	defer s.Close()
+
+	upload := func(checksum string) (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodPut, s.URL+"/upload", io.NopCloser(strings.NewReader("chunk")))
+		req.Trailer = http.Header{"X-Checksum": []string{checksum}}
+		return s.Client().Do(req)
+	}
+
+	// the mismatching trailer runs the responder out of data, which aborts
+	// the connection in server mode
+	_, err := upload("nope")
+	assert.Error(t, err)
+
+	resp, err := upload("cafe")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	history := mrClient.History()
+	assert.Equal(t, "cafe", history[len(history)-1].Request.Trailer.Get("X-Checksum"))
+	assert.Equal(t, []byte("chunk"), history[len(history)-1].Request.Body)
+}
+
+func TestMockResponder_MatchMethod(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/thing$", Method: http.MethodDelete, Code: http.StatusNoContent},
+		MockResp{URL: "/thing$", Method: http.MethodGet, Data: []byte(`thing`)},
+	})
+	assert.Empty(t, mrClient.GetData().Shadowed())
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/thing", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodDelete, "/thing", nil)
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestMockResponder_MatchHeaders(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`admin`), MatchHeaders: map[string]string{"Authorization": "^Bearer admin-", "X-Request-Id": "."}},
+		MockResp{Data: []byte(`user`), MatchHeaders: map[string]string{"Authorization": "^Bearer "}},
+	})
+
+	get := func(token string) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Request-Id", "42")
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	assert.Equal(t, "user", get("user-1"))
+	assert.Equal(t, "admin", get("admin-1"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/bla", nil)
+	assert.Equal(t, `header "Authorization" is missing`, mrClient.mismatch(&MockResp{MatchHeaders: map[string]string{"Authorization": "."}}, req))
+}
+
+func TestMockResponder_MatchCookies(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`devices`), MatchCookies: map[string]string{"session": "^[0-9a-f]+$"}},
+		MockResp{Code: http.StatusUnauthorized, Priority: -1},
+	})
+
+	get := func(session string) int {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/devices", nil)
+		if len(session) > 0 {
+			req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		}
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusUnauthorized, get(""))
+	assert.Equal(t, http.StatusOK, get("c0ffee"))
+
+	want := &MockResp{MatchCookies: map[string]string{"session": "^[0-9a-f]+$"}}
+	req, _ := http.NewRequest(http.MethodGet, "/devices", nil)
+	assert.Equal(t, `cookie "session" is missing`, mrClient.mismatch(want, req))
+	req.AddCookie(&http.Cookie{Name: "session", Value: "expired"})
+	assert.Equal(t, `cookie "session" "expired" does not match "^[0-9a-f]+$"`, mrClient.mismatch(want, req))
+}
+
+func TestMockResponder_MatchContentType(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/upload$", MatchContentType: "multipart/form-data", Data: []byte(`multipart`)},
+		MockResp{URL: "/upload$", MatchContentType: "application/json; charset=utf-8", Data: []byte(`json`)},
+	})
+
+	upload := func(contentType string) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/upload", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	assert.Equal(t, "json", upload("Application/JSON; charset=UTF-8"))
+	assert.Equal(t, "multipart", upload("multipart/form-data; boundary=xyz"))
+
+	want := &MockResp{MatchContentType: "application/json; charset=utf-8"}
+	req, _ := http.NewRequest(http.MethodPost, "/upload", nil)
+	req.Header.Set("Content-Type", "application/json")
+	assert.Equal(t, `content type "application/json" is not "application/json; charset=utf-8"`, mrClient.mismatch(want, req))
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	assert.Equal(t, `content type "text/plain; charset=utf-8" is not "application/json; charset=utf-8"`, mrClient.mismatch(want, req))
+	assert.ErrorContains(t, mrClient.patternError(&MockResp{MatchContentType: "/;"}), `invalid content type "/;"`)
+}
+
+func TestMockResponder_BodyPattern(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/devices$", BodyPattern: `"kind":\s*"router"`, Data: []byte(`router`)},
+		MockResp{URL: "/devices$", BodyPattern: `"kind":\s*"switch"`, Data: []byte(`switch`)},
+	})
+
+	create := func(kind string) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/devices", strings.NewReader(`{"kind": "`+kind+`"}`))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	assert.Equal(t, "switch", create("switch"))
+	assert.Equal(t, "router", create("router"))
+
+	req, _ := http.NewRequest(http.MethodPost, "/devices", strings.NewReader(`{}`))
+	assert.Equal(t, `body does not match "x"`, mrClient.mismatch(&MockResp{BodyPattern: "x"}, req))
+}
+
+func TestMockResponder_MatchJSON(t *testing.T) {
+	type device struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{MatchJSON: device{Name: "r1", Tags: []string{"core"}}, Data: []byte(`r1`)},
+		MockResp{MatchJSON: json.RawMessage(`{"name": "r2", "tags": []}`), Data: []byte(`r2`)},
+	})
+
+	create := func(body string) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/devices", strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Equal(t, "r2", create(`{"tags": [], "name": "r2"}`))
+	assert.Equal(t, "r1", create("{\n  \"tags\": [\"core\"],\n  \"name\": \"r1\"\n}"))
+
+	req, _ := http.NewRequest(http.MethodPost, "/devices", strings.NewReader(`{"name": "r3", "tags": ["edge"]}`))
+	assert.Equal(t, `JSON body differs: $.name: got "r3", want "r1"; $.tags[0]: got "edge", want "core"`,
+		mrClient.mismatch(&MockResp{MatchJSON: device{Name: "r1", Tags: []string{"core"}}}, req))
+	req, _ = http.NewRequest(http.MethodPost, "/devices", strings.NewReader(`name=r1`))
+	assert.Equal(t, "body is not JSON", mrClient.mismatch(&MockResp{MatchJSON: device{}}, req))
+}
+
+func TestMockResponder_MatchForm(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{MatchForm: url.Values{"grant_type": {"refresh_token"}}, Data: []byte(`refreshed`)},
+		MockResp{MatchForm: url.Values{"grant_type": {"password"}, "username": {"admin"}}, Data: []byte(`token`)},
+	})
+
+	login := func(form url.Values) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Equal(t, "token", login(url.Values{"grant_type": {"password"}, "username": {"admin"}, "password": {"secret"}}))
+	assert.Equal(t, "refreshed", login(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"abc"}}))
+
+	want := &MockResp{MatchForm: url.Values{"username": {"admin"}}}
+	req, _ := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(`username=guest`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, `form field "username" ["guest"] does not hold "admin"`, mrClient.mismatch(want, req))
+	req, _ = http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(`{"username": "admin"}`))
+	assert.Equal(t, "body is not a URL-encoded form", mrClient.mismatch(want, req))
+}
+
+func TestMockResponder_Matcher(t *testing.T) {
+	type tenantKey struct{}
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/devices$", Data: []byte(`acme`), Matcher: func(req *http.Request) bool {
+			return req.Context().Value(tenantKey{}) == "acme"
+		}},
+		MockResp{URL: "/devices$", Data: []byte(`other`)},
+	})
+
+	get := func(tenant string) string {
+		req, _ := http.NewRequestWithContext(context.WithValue(ctx, tenantKey{}, tenant), http.MethodGet, "/devices", nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Equal(t, "other", get("umbrella"))
+	assert.Equal(t, "acme", get("acme"))
+
+	// the matcher is only called for requests matching the URL
+	called := false
+	stub := MockResp{URL: "/devices$", Matcher: func(*http.Request) bool { called = true; return true }}
+	req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+	assert.NotEmpty(t, mrClient.mismatch(&stub, req))
+	assert.False(t, called)
+}
+
+func TestMockResponder_SetMatchPathOnly(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	stub := MockResp{URL: "^/devices/[0-9]+$"}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/devices/42?expand=true", nil)
+	assert.Equal(t, `URL "https://api.example.com/devices/42?expand=true" does not match "^/devices/[0-9]+$"`, mrClient.mismatch(&stub, req))
+
+	mrClient.SetMatchPathOnly(true)
+	assert.Empty(t, mrClient.mismatch(&stub, req))
+	req, _ = http.NewRequest(http.MethodGet, "https://api.example.com/devices/x", nil)
+	assert.Equal(t, `URL "/devices/x" does not match "^/devices/[0-9]+$"`, mrClient.mismatch(&stub, req))
+}
+
+func TestMockResponder_SetIgnoreURLCase(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	stub := MockResp{URL: "/api/Devices/[0-9]+$"}
+	glob := MockResp{URL: "/api/Devices/*", Glob: true}
+	req, _ := http.NewRequest(http.MethodGet, "http://bla/API/devices/42", nil)
+	assert.Equal(t, `URL "http://bla/API/devices/42" does not match "/api/Devices/[0-9]+$"`, mrClient.mismatch(&stub, req))
+	assert.Equal(t, `path "/API/devices/42" does not match glob "/api/Devices/*"`, mrClient.mismatch(&glob, req))
+
+	mrClient.SetIgnoreURLCase(true)
+	assert.Empty(t, mrClient.mismatch(&stub, req))
+	assert.Empty(t, mrClient.mismatch(&glob, req))
+	req, _ = http.NewRequest(http.MethodGet, "http://bla/API/ports/42", nil)
+	assert.NotEmpty(t, mrClient.mismatch(&stub, req))
+	assert.NotEmpty(t, mrClient.mismatch(&glob, req))
+}
+
+func TestMockResponder_NotURL(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{NotURL: "/health$", Code: http.StatusServiceUnavailable},
+		MockResp{URL: "/health$"},
+	})
+	assert.Empty(t, MockRespList{
+		MockResp{NotURL: "/health$"},
+		MockResp{URL: "/health$"},
+	}.Shadowed())
+
+	get := func(url string) int {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, get("http://bla/health"))
+	assert.Equal(t, http.StatusServiceUnavailable, get("http://bla/devices"))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://bla/health", nil)
+	assert.Equal(t, `URL "http://bla/health" matches excluded "/health$"`, mrClient.mismatch(&MockResp{NotURL: "/health$"}, req))
+	assert.Error(t, mrClient.patternError(&MockResp{NotURL: "("}))
+}
+
+func TestMockResponder_Glob(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	stub := MockResp{URL: "/api/v1/devices/*/interfaces", Glob: true}
+	req, _ := http.NewRequest(http.MethodGet, "http://bla/api/v1/devices/r1/interfaces?all=1", nil)
+	assert.Empty(t, mrClient.mismatch(&stub, req))
+	req, _ = http.NewRequest(http.MethodGet, "http://bla/api/v1/devices/r1/x/interfaces", nil)
+	assert.Equal(t, `path "/api/v1/devices/r1/x/interfaces" does not match glob "/api/v1/devices/*/interfaces"`, mrClient.mismatch(&stub, req))
+
+	// globs aren't regular expressions
+	assert.NoError(t, mrClient.patternError(&MockResp{URL: "/a/(", Glob: true}))
+	assert.EqualError(t, mrClient.patternError(&MockResp{URL: "/a/[", Glob: true}), `invalid glob "/a/[": syntax error in pattern`)
+}
+
+func TestMockResponder_ValidFor(t *testing.T) {
+	now := time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetClock(func() time.Time { return now })
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/download$", Data: []byte(`presigned`), ValidFor: time.Minute},
+		MockResp{URL: "/download$", Code: http.StatusForbidden},
+	})
+
+	now = now.Add(time.Minute)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/download", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "expired 1m0s after registration", mrClient.mismatch(&mrClient.mockData[0], req))
+
+	// setting the data registers the responses again
+	mrClient.SetData(mrClient.GetData())
+	assert.Empty(t, mrClient.mismatch(&mrClient.mockData[0], req))
+}
+
+func TestMockResponder_Priority(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`catch-all`), Priority: -1},
+		MockResp{URL: "/devices$", Data: []byte(`devices`)},
+		MockResp{URL: "/devices$", Data: []byte(`urgent`), Priority: 1},
+	})
+
+	get := func(url string) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Equal(t, "urgent", get("/devices"))
+	assert.Equal(t, "devices", get("/devices"))
+	assert.Equal(t, "catch-all", get("/devices"))
+}
@@ -0,0 +1,43 @@
+package mockresponder
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// MisconfigurationHeader is the response header which carries the error of a
+// misconfigured mocked response, see SetMisconfigurationResp.
+const MisconfigurationHeader = "X-Mock-Misconfiguration"
+
+// SetMisconfigurationResp sets the response which is served instead of a
+// mocked response whose patterns are invalid, e.g. a URL regex which fails to
+// compile.  By default, a 500 "mock misconfiguration" response carrying the
+// error is served.  The error is always set in the MisconfigurationHeader and
+// recorded as a violation, see Violations().  This keeps harness servers alive
+// while clearly signalling the broken response.
+func (m *MockResponder) SetMisconfigurationResp(resp MockResp) {
+	m.mu.Lock()
+	m.misconfigResp = &resp
+	m.mu.Unlock()
+}
+
+// misconfigured answers a request which hit the misconfigured response at idx.
+func (m *MockResponder) misconfigured(req *http.Request, idx int, err error) (*http.Response, error) {
+	msg := fmt.Sprintf("mock misconfiguration: response %d (%q): %s", idx, m.mockData[idx].URL, err)
+	m.violate("%s", msg)
+	m.record(req, -1, "")
+	resp := MockResp{
+		Code:   http.StatusInternalServerError,
+		Data:   []byte(msg + "\n"),
+		Header: http.Header{"Content-Type": []string{"text/plain; charset=utf-8"}},
+	}
+	if m.misconfigResp != nil {
+		resp = *m.misconfigResp
+	}
+	resp.Header = resp.Header.Clone()
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	resp.Header.Set(MisconfigurationHeader, msg)
+	return m.respond(req, resp)
+}
@@ -0,0 +1,53 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_misconfigured(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "ok$", Data: []byte(`OK`)},
+		MockResp{URL: "/devices/(", Data: []byte(`NAK`)},
+	})
+
+	get := func(url string) (*http.Response, string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	// the broken response is only hit once preceding responses don't match
+	_, body := get("/ok")
+	assert.Equal(t, "OK", body)
+	assert.Empty(t, mrClient.Violations())
+
+	msg := "mock misconfiguration: response 1 (\"/devices/(\"): invalid pattern \"/devices/(\": " +
+		"error parsing regexp: missing closing ): `/devices/(`"
+	resp, body := get("/devices/1")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, msg+"\n", body)
+	assert.Equal(t, msg, resp.Header.Get(MisconfigurationHeader))
+	assert.Equal(t, []string{msg}, mrClient.Violations())
+
+	mrClient.SetMisconfigurationResp(ProblemResp(http.StatusServiceUnavailable, "", "broken mock", ""))
+	resp, _ = get("/devices/1")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, msg, resp.Header.Get(MisconfigurationHeader))
+	assert.False(t, mrClient.Empty())
+
+	// the chain answers with the misconfiguration as well
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/devices/1", nil)
+	resp, err := Chain(mrClient).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -3,26 +3,217 @@ package mockresponder
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math/rand"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
 )
 
 // MockResp is a mock response, the URL can be a RegEx, in this case the first
 // response in the list of unserved responses which matches the RegEx will be
 // served.  If no Regex is provided, the first unserved response is served.  The
-// default status code is 200, can be overwritten in Code.  If Err is provided,
-// then this error will be returned.
+// default status code is 200, can be overwritten in Code.  Header holds
+// additional response headers.  If Err is provided, then this error will be
+// returned.  The optional Name identifies the response in helpers like
+// WaitForServed and the optional Tag groups responses of a flow (e.g.
+// "billing"), see EmptyForTag.  If Glob is set, URL is a shell glob instead
+// of a RegEx which is matched against the path of the request, e.g.
+// "/api/v1/devices/*/ports" where "*" matches any sequence of characters other
+// than "/", see path.Match.  NotURL excludes requests whose URL matches the
+// RegEx, e.g. a catch-all for everything but "/health$".  PathTemplate
+// restricts the response to requests whose path matches the template, e.g.
+// "/devices/{id}/ports/{port}" where every parameter matches a single path
+// segment, the captured parameters replace "{id}" and "{port}" in the body and
+// header values of the response.  If several unserved responses match a
+// request, the one with the highest Priority is served, responses with the
+// same priority are served in order.  This allows to define catch-all
+// responses with a low priority first.
+//
+// Matcher implements arbitrary matching logic, the response is only served if
+// it returns true for the request.  It's called after the URL check and can
+// inspect the headers, body and context of the request.
+//
+// Method restricts the response to requests with the given HTTP method (e.g.
+// "DELETE"), regardless of case.  Host restricts the response to requests to
+// the given host (e.g. "auth.example.com"), taken from the Host header or the
+// URL, a host without a port matches any port.  Scheme and Proto restrict the
+// response to requests using the given URL scheme (e.g. "https") and HTTP
+// protocol version (e.g. "HTTP/2.0", only meaningful in server mode).
+// MatchHeaders and MatchTrailers restrict the response to requests carrying
+// the given headers and trailers, the values are regular expressions matched
+// against the header and trailer values.  MatchCookies likewise restricts
+// the response to requests carrying the given cookies, the values are regular
+// expressions matched against the cookie values.
+// MatchBasicAuth restricts the response to requests with the given basic
+// authentication credentials in the Authorization header and
+// MatchBearerToken to requests with the given bearer token.
+// MatchContentType restricts the response to requests with the given media
+// type (e.g. "multipart/form-data"), parameters of the given content type
+// (e.g. "charset=utf-8") must be present in the request's content type.
+// BodyPattern restricts the response to requests whose body matches the
+// regular expression, compressed bodies are matched after decompression.
+// MatchBody restricts the response to requests whose body equals MatchBody
+// according to the named BodyComparator, by default CompareExact, see
+// RegisterBodyComparator.
+// MatchJSON restricts the response to requests with a JSON body which is
+// structurally equal to the given value, ignoring key order and whitespace.
+// The value is marshalled to JSON unless it's JSON already ([]byte or
+// json.RawMessage).  MatchXML restricts the response to requests with an XML
+// body which is equal to the given document, ignoring attribute order,
+// namespace prefixes, comments and whitespace around text.  MatchForm
+// restricts the response to requests with a URL-encoded form body holding the
+// given values for the given keys, other keys are ignored.  MatchMultipart
+// restricts the response to multipart/form-data requests with the given form
+// fields, files and part content types.  SOAPAction
+// restricts the response to requests with the given SOAP action, taken from
+// the SOAPAction header or the action parameter of the content type, and
+// SOAPOperation to requests whose SOAP body holds the given operation element
+// (the local name, e.g. "GetQuote").
+//
+// SeqHeader and SeqField check a monotonically increasing sequence token of
+// the requests served by the response, taken from the given request header or
+// the given field of a JSON request body (nested fields are separated by
+// dots, e.g. "meta.seq").  If the token is missing or not strictly greater
+// than the token of the previous request which was checked for the same header
+// or field, a violation is recorded, see Violations().  Integer tokens are
+// compared numerically, other tokens lexically.
+//
+// ValidFor limits the response to requests within the given duration after it
+// was set via SetData, measured on the responder's clock.  This simulates
+// expiring presigned URLs and short-lived endpoints which clients must
+// refresh.
+//
+// CheckContext records a violation for every request served by the response
+// whose context lacks the given properties, e.g. a deadline, which verifies
+// that the client bounds its outbound calls.  In server mode, the context is
+// the one of the server's request.
+//
+// Final marks the response as the last one the client may request, e.g. the
+// logout at the end of a shutdown sequence.  Every request which arrives
+// after the response has been served is recorded as a violation, see
+// Violations().
+//
+// Languages holds variants of the body per language tag which are selected by
+// the Accept-Language header of the request.  If no variant is acceptable, the
+// LanguageFallback variant is served or, if there's no such variant, Data.
+//
+// DataFunc computes the body from the request instead of Data when the
+// response is served, e.g. to echo identifiers or generate pagination cursors.
+//
+// BodyReader streams the body from the reader instead of Data, which allows
+// to serve large or incrementally produced bodies without buffering them.  The
+// reader is consumed by the first response and closed with the response body
+// if it's an io.Closer.  As the length of the body is unknown, the content
+// length of the response is -1 and the body is neither encoded nor checksummed.
+//
+// DataFile streams the body from the file at the given path instead of Data,
+// like BodyReader the file is opened when the response is served and isn't
+// loaded into memory.  A file which doesn't exist is logged when the data is
+// set and Do returns the error of opening it when the response is served.
+//
+// BodyErr and BodyHang simulate a failure after the status and headers have
+// been delivered successfully: once Data has been read, reading the body
+// either returns BodyErr or blocks until the body is closed or the request
+// context is done.
+//
+// Informational holds 1xx informational responses, e.g. 103 Early Hints, which
+// are sent ahead of the final response in server mode and delivered to the
+// Got1xxResponse hook of an httptrace.ClientTrace of the request by Do().
+//
+// Raw holds the recorded wire data of a complete response, status line,
+// headers and body, which is replayed byte for byte in server mode, including
+// exotic header casing and ordering, and parsed via http.ReadResponse by Do().
+// Raw replaces all other response fields.
+//
+// Respond builds the complete response to the request, which allows a single
+// response to be fully dynamic while the other responses remain declarative.
+// It replaces Code and the body fields, Header is added to the headers of the
+// built response.
+//
+// Delay holds back the response for the given duration, on top of the
+// simulated latency, see SetLatency().  Unlike the latency it isn't scaled,
+// which allows to exercise client timeouts deterministically.  If DelayMax is
+// set, a random delay between DelayMin and DelayMax is added, picked by the
+// responder's random number generator which can be seeded, see SetSeed().
+//
+// Creates simulates the creation of a resource, e.g. by a POST request.  When
+// the response is served, the JSON body of the request is stored as the
+// resource at the given path where "{id}" is replaced by the id field of the
+// body or, if there's none, a generated identifier which is added to the body.
+// Subsequent GET, PUT and DELETE requests to that path read, replace and
+// delete the stored resource, ahead of the mocked responses.  The response
+// defaults to status 201 with the stored resource as body and the Location
+// header set to the path.
 type MockResp struct {
-	Data   []byte
-	Code   int
-	URL    string
-	Err    error
-	served bool
+	Data         []byte
+	Code         int
+	URL          string
+	NotURL       string
+	PathTemplate string
+	Glob         bool
+	Err          error
+	Name         string
+	Tag          string
+	Priority     int
+
+	// request matchers
+	Matcher          func(req *http.Request) bool
+	Method           string
+	Host             string
+	Scheme           string
+	Proto            string
+	MatchHeaders     map[string]string
+	MatchTrailers    map[string]string
+	MatchCookies     map[string]string
+	MatchBasicAuth   *Credentials
+	MatchBearerToken string
+	MatchContentType string
+	BodyPattern      string
+	MatchBody        []byte
+	BodyComparator   string
+	MatchJSON        any
+	MatchXML         []byte
+	MatchForm        url.Values
+	MatchMultipart   *MultipartMatch
+	SOAPAction       string
+	SOAPOperation    string
+
+	// request checks
+	SeqHeader    string
+	SeqField     string
+	ValidFor     time.Duration
+	CheckContext *ContextCheck
+	Final        bool
+
+	// response details
+	Header           http.Header
+	DataFunc         func(req *http.Request) []byte
+	BodyReader       io.Reader
+	DataFile         string
+	Languages        map[string][]byte
+	LanguageFallback string
+	BodyErr          error
+	BodyHang         bool
+	Raw              []byte
+	Respond          func(req *http.Request) (*http.Response, error)
+	Creates          string
+	Informational    []Informational
+	Delay            time.Duration
+	DelayMin         time.Duration
+	DelayMax         time.Duration
+
+	served     bool
+	registered time.Time
 }
 
 func (mr MockResp) String() string {
@@ -34,18 +225,159 @@ func (mr MockResp) String() string {
 // MockResponder serves, either sequentially or because of a RegEx match.
 type MockRespList []MockResp
 
+// Doer is the interface of http.Client.Do() which is satisfied by the mock
+// responder.  Client code and test helpers can accept a Doer to work with an
+// http.Client, a MockResponder or any other implementation.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Stubber is the interface to manage the mocked responses of a responder.
+type Stubber interface {
+	SetData(data MockRespList)
+	Reset()
+	Empty() bool
+}
+
+var (
+	_ Doer    = (*MockResponder)(nil)
+	_ Stubber = (*MockResponder)(nil)
+	_ Doer    = (*ResponderChain)(nil)
+	_ Doer    = (*http.Client)(nil)
+)
+
 type contextKey string
 
 const (
 	contextMockClient = contextKey("mockclient")
 )
 
+const noContextHint = "no MockResponder in request context: create requests via " +
+	"http.NewRequestWithContext() with the context returned by NewMockResponder(), " +
+	"use the responder as http.Client Transport, see AttachResponder() or SetContextPolicy()"
+
+// ErrNoContext is returned for requests without the mock responder context if
+// the ContextError policy is set.
+var ErrNoContext = errors.New(noContextHint)
+
+var (
+	// ErrOutOfData is the failure when no unserved response matches a
+	// request.
+	ErrOutOfData = errors.New("ran out of data")
+	// ErrTooManyRequests is the failure when a request exceeds the limit set
+	// via SetMaxRequests.
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrClosed is returned for requests to a closed responder.
+	ErrClosed = errors.New("mock responder is closed")
+	// ErrReset is the failure of requests which were in flight while the
+	// responder was reset.
+	ErrReset = errors.New("responder was reset")
+	// ErrInjected is the transport error of requests failed by the failure
+	// injection, see SetFailRate.
+	ErrInjected = errors.New("injected failure")
+	// ErrHookTimeout is the failure of a user-provided function which did not
+	// return in time, see SetHookTimeout.
+	ErrHookTimeout = errors.New("hook timed out")
+)
+
+// FailurePolicy defines how the responder fails, e.g. when it runs out of
+// data.
+type FailurePolicy int
+
+const (
+	// FailPanic panics, this is the default.
+	FailPanic FailurePolicy = iota
+	// FailError returns the failure as error from Do().
+	FailError
+)
+
+// ContextPolicy defines how the responder handles requests which don't carry
+// the context returned by NewMockResponder.
+type ContextPolicy int
+
+const (
+	// ContextPanic panics, this is the default.
+	ContextPanic ContextPolicy = iota
+	// ContextFallback serves the request with the responder whose Do() was
+	// called.
+	ContextFallback
+	// ContextError returns ErrNoContext.
+	ContextError
+)
+
 // MockResponder serves mock responses
 type MockResponder struct {
-	doFunc     func(req *http.Request) (*http.Response, error)
-	mockData   MockRespList
-	lastServed int
+	// generation is incremented whenever the responder is reset, accessed
+	// atomically
+	generation uint64
+
+	doFunc        func(req *http.Request) (*http.Response, error)
+	mockData      MockRespList
+	lastServed    int
+	delay         time.Duration
+	final         string
+	customDo      bool
+	contextPolicy ContextPolicy
+	failurePolicy FailurePolicy
+	requests      int
+	maxRequests   int
+
+	collapseWindow   time.Duration
+	collapsed        collapsed
+	checksums        []Checksum
+	outages          []outage
+	budget           time.Duration
+	lastRequest      time.Time
+	violations       []string
+	dryRun           *[]string
+	hostCheck        bool
+	expectedHost     string
+	sequences        map[string]string
+	latencyBase      time.Duration
+	latencyScale     float64
+	latencyProfiles  []LatencyProfile
+	failRate         float64
+	seed             int64
+	rng              *rand.Rand
+	historyLimit     int
+	matchPathOnly    bool
+	ignoreURLCase    bool
+	misconfigResp    *MockResp
+	stubHeader       bool
+	resources        map[string][]byte
+	pending          map[string]resourceWrite
+	visibilityLag    time.Duration
+	visibilityReads  int
+	regexps          map[string]*regexp.Regexp
+	index            *stubIndex
+	strategy         MatchStrategy
+	strictValidation bool
+	rawCapture       int32
+	hookTimeout      time.Duration
+	slowPatterns     map[string]bool
+	lastResourceID   int
+
+	servers    []*httptest.Server
+	closed     bool
+	id         string
 	mu         sync.Mutex
+	servedCond *sync.Cond
+	fallback   *MockResp
+	api        *API
+	unexpected []CapturedRequest
+	bodies     []*mockBody
+	undrained  []string
+	history    []Interaction
+	store      HistoryStore
+	seq        int
+	dropped    int
+	ca         *testCA
+	encodeBody bool
+	logger     func(format string, args ...any)
+	logMu      sync.Mutex
+	clock      func() time.Time
+	expires    time.Duration
+	age        time.Duration
 }
 
 func sanitizeURL(url string) string {
@@ -61,116 +393,622 @@ func sanitizeURL(url string) string {
 		}, url)
 }
 
-// defaultDoFunc is the default implementation to return mocked responses
-// as defined in the response list of the mock responder.
-func defaultDoFunc(req *http.Request) (*http.Response, error) {
-	ctxValue := req.Context().Value(contextMockClient)
+// responderFromContext returns the mock responder which is stored in the
+// context of the request.
+func responderFromContext(req *http.Request) *MockResponder {
+	ctxValue := discover(req)
 	if ctxValue == nil {
-		panic("no MockResponse context")
+		panic(noContextHint)
 	}
 	mc, ok := ctxValue.(*MockResponder)
 	if !ok {
 		panic("returned value is not a MockResponder!")
 	}
-
-	log.Printf("mock request url %s %s", req.Method, sanitizeURL(req.URL.String()))
 	if mc == nil {
 		panic("no data")
 	}
+	return mc
+}
+
+// defaultDoFunc is the default implementation to return mocked responses
+// as defined in the response list of the mock responder.
+func defaultDoFunc(req *http.Request) (*http.Response, error) {
+	mc := responderFromContext(req)
+	mc.logf("mock request url %s %s", req.Method, sanitizeURL(req.URL.String()))
+
+	mc.requests++
+	mc.checkBudget(req)
+	mc.checkHost(req)
+	mc.checkFinal(req)
+
+	if mc.injectFailure() {
+		mc.logf("injected failure, request %s %s fails", req.Method, sanitizeURL(req.URL.String()))
+		mc.record(req, -1, "")
+		return nil, ErrInjected
+	}
+	if mc.maxRequests > 0 && mc.requests > mc.maxRequests {
+		return mc.fail(fmt.Errorf("%w: request %d exceeds the limit of %d", ErrTooManyRequests, mc.requests, mc.maxRequests))
+	}
 
-	var (
-		idx  int
-		data MockResp
-	)
+	if outage, ok := mc.outageResp(); ok {
+		mc.logf("outage, request %s %s fails", req.Method, sanitizeURL(req.URL.String()))
+		mc.record(req, -1, "")
+		return mc.respond(req, outage)
+	}
 
-	found := false
-	for idx, data = range mc.mockData {
-		if data.served {
-			continue
+	if idx, ok := mc.collapse(req); ok {
+		mc.logf("collapsed duplicate request %s %s", req.Method, sanitizeURL(req.URL.String()))
+		mc.record(req, idx, mc.mockData[idx].Name)
+		return mc.respond(req, mc.stubResp(req, idx, false))
+	}
+
+	if resource, ok := mc.resourceResp(req); ok {
+		mc.logf("resource request %s %s", req.Method, sanitizeURL(req.URL.String()))
+		mc.record(req, -1, "")
+		return mc.respond(req, resource)
+	}
+
+	idx, found, err := mc.match(req)
+	if err != nil {
+		return mc.misconfigured(req, idx, err)
+	}
+	if !found {
+		if resp, ok, err := mc.serveAPI(req); ok {
+			return resp, err
 		}
-		if len(data.URL) > 0 {
-			m, err := regexp.MatchString(data.URL, req.URL.String())
-			if err != nil {
-				panic("regex pattern issue")
-			}
-			if !m {
-				continue
+	}
+	if !found && mc.fallback != nil {
+		mc.logf("unexpected request %s %s, serving fallback", req.Method, sanitizeURL(req.URL.String()))
+		mc.unexpected = append(mc.unexpected, mc.record(req, -1, mc.fallback.Name))
+		return mc.respond(req, mc.stampStub(*mc.fallback, "fallback"))
+	}
+	if !found {
+		mc.logf("out of data for request %s %s", req.Method, sanitizeURL(req.URL.String()))
+		for k := range mc.mockData {
+			data := &mc.mockData[k]
+			reason := "already served"
+			if !data.served {
+				reason = mc.mismatch(data, req)
 			}
+			mc.logf("%d (%q): %s", k, data.URL, reason)
 		}
-		// need to change the array element, not the copy in "data"
-		mc.mockData[idx].served = true
-		mc.lastServed = idx
-		found = true
-		break
+		return mc.fail(ErrOutOfData)
 	}
+	return mc.serve(req, idx)
+}
+
+// fail handles a failure of the responder according to its failure policy.
+func (m *MockResponder) fail(err error) (*http.Response, error) {
+	if m.failurePolicy == FailError {
+		return nil, err
+	}
+	panic(err.Error())
+}
+
+// serve marks the response at idx as served and returns it.
+func (m *MockResponder) serve(req *http.Request, idx int) (*http.Response, error) {
+	// need to change the array element, not a copy
+	m.mockData[idx].served = true
+	m.lastServed = idx
+	if m.mockData[idx].Final {
+		m.final = m.stubLabel(idx)
+	}
+	m.consumed(req, idx)
+	m.checkSequence(&m.mockData[idx], req)
+	m.checkContext(&m.mockData[idx], req)
+	m.record(req, idx, m.mockData[idx].Name)
+	m.servedCond.Broadcast()
+	return m.respond(req, m.stubResp(req, idx, true))
+}
+
+// stubResp returns the mocked data at idx for the request, with the
+// parameters of its path template substituted and stamped with its StubHeader
+// value, see SetStubHeader.  The resource of a response
+// which creates one is only created if create is set, a duplicate request
+// served the same response again doesn't create another one.
+func (m *MockResponder) stubResp(req *http.Request, idx int, create bool) MockResp {
+	data := m.mockData[idx]
+	if len(data.PathTemplate) > 0 {
+		data = m.expandTemplate(req, data)
+	}
+	if create && len(data.Creates) > 0 {
+		data = m.createResource(req, data)
+	}
+	return m.stampStub(data, m.stubLabel(idx))
+}
 
+// respond builds the response for the given mocked data.
+func (m *MockResponder) respond(req *http.Request, data MockResp) (*http.Response, error) {
 	// default to 200/OK
 	statusCode := data.Code
 	if statusCode == 0 {
 		statusCode = http.StatusOK
 	}
 
-	if found {
-		// log.Printf("%s <%v>, %d: %v\n", req.Method, req.URL, statusCode, string(data.Data))
-		log.Printf("%s <%v>, %d: %s\n", req.Method, req.URL, statusCode, data)
-	} else {
-		for k, v := range mc.mockData {
-			log.Printf("%d: %v %v %v\n%v\n%v\n", k, v.served, v.URL, v.Code, sanitizeURL(req.URL.String()), string(v.Data))
-			log.Println("**********")
-		}
-		panic("ran out of data")
-	}
+	// log.Printf("%s <%v>, %d: %v\n", req.Method, req.URL, statusCode, string(data.Data))
+	m.logf("%s <%v>, %d: %s\n", req.Method, req.URL, statusCode, data)
+	m.delay = m.delayFor(data)
 
 	if data.Err != nil {
 		return nil, data.Err
 	}
+	if err := sendInformational(req, data.Informational); err != nil {
+		return nil, err
+	}
+	if data.Respond != nil {
+		return m.respondFunc(req, data)
+	}
+	if len(data.Raw) > 0 {
+		return rawResponse(req, data.Raw)
+	}
+
+	if data.DataFunc != nil {
+		fn := data.DataFunc
+		var body []byte
+		what := func() string { return "data function of " + m.describe(&data) }
+		if err := m.guard(what, func() { body = fn(req) }); err != nil {
+			return nil, err
+		}
+		data.Data = body
+	}
+	if len(data.DataFile) > 0 {
+		f, err := os.Open(data.DataFile)
+		if err != nil {
+			return nil, err
+		}
+		data.BodyReader = f
+	}
+	if data.BodyReader != nil {
+		return m.streamResponse(req, statusCode, data)
+	}
+
+	body := data.Data
+	header := make(http.Header)
+	m.stampDate(header)
+	if len(data.Languages) > 0 {
+		body = selectLanguage(req, header, data)
+	}
+	for k, v := range data.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	if m.encodeBody {
+		var err error
+		if body, err = encodeBody(req, header, body); err != nil {
+			return nil, err
+		}
+	}
+	m.addChecksums(header, body)
 
+	mb := newMockBody(req.Context(), bytes.NewReader(body), data)
 	resp := &http.Response{
-		StatusCode: statusCode,
-		Body:       io.NopCloser(bytes.NewReader([]byte(data.Data))),
-		Header:     make(http.Header),
+		StatusCode:    statusCode,
+		Body:          mb,
+		Header:        header,
+		ContentLength: int64(len(body)),
 	}
+	if !bodyAllowed(statusCode) {
+		resp.Body = http.NoBody
+		resp.ContentLength = 0
+		return resp, nil
+	}
+	m.trackBody(req, mb)
+	return resp, nil
+}
+
+// respondFunc returns the response built by the Respond function of the
+// mocked data.  Like other responses, it carries the date and checksum headers
+// and the Header of the mocked data, e.g. the StubHeader, and its body is
+// tracked.
+func (m *MockResponder) respondFunc(req *http.Request, data MockResp) (*http.Response, error) {
+	fn := data.Respond
+	var resp *http.Response
+	var err error
+	what := func() string { return "respond function of " + m.describe(&data) }
+	if gerr := m.guard(what, func() { resp, err = fn(req) }); gerr != nil {
+		return nil, gerr
+	}
+	if resp == nil {
+		if err == nil {
+			err = fmt.Errorf("respond function of %s returned neither a response nor an error", m.describe(&data))
+		}
+		return nil, err
+	}
+	header := make(http.Header)
+	m.stampDate(header)
+	for k, v := range resp.Header {
+		header[k] = v
+	}
+	for k, v := range data.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	resp.Header = header
+	if resp.Body == nil || resp.Body == http.NoBody {
+		m.addChecksums(header, nil)
+		return resp, err
+	}
+	if len(m.checksums) > 0 {
+		body, rerr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if rerr != nil {
+			return nil, rerr
+		}
+		m.addChecksums(header, body)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	mb := newMockBody(req.Context(), resp.Body, data)
+	resp.Body = mb
+	m.trackBody(req, mb)
+	return resp, err
+}
+
+// streamResponse builds the response for mocked data with a BodyReader.
+func (m *MockResponder) streamResponse(req *http.Request, statusCode int, data MockResp) (*http.Response, error) {
+	header := make(http.Header)
+	m.stampDate(header)
+	for k, v := range data.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	mb := newMockBody(req.Context(), data.BodyReader, data)
+	resp := &http.Response{
+		StatusCode:    statusCode,
+		Body:          mb,
+		Header:        header,
+		ContentLength: -1,
+	}
+	if !bodyAllowed(statusCode) {
+		mb.Close()
+		resp.Body = http.NoBody
+		resp.ContentLength = 0
+		return resp, nil
+	}
+	m.trackBody(req, mb)
 	return resp, nil
 }
 
 // Do satisfies the http.Client.Do() interface
 func (m *MockResponder) Do(req *http.Request) (*http.Response, error) {
+	resp, _, err := m.dispatch(req, false)
+	return resp, err
+}
+
+// dispatch serves the request and simulates the latency of the response.  As
+// part of a chain, the responder declines requests which it can't serve and
+// returns false, see ResponderChain.
+func (m *MockResponder) dispatch(req *http.Request, chained bool) (*http.Response, bool, error) {
+	generation := atomic.LoadUint64(&m.generation)
+	resp, ok, delay, err := m.do(req, generation, chained)
+	if !ok {
+		return nil, false, nil
+	}
+	if delay > 0 {
+		// the latency is simulated outside of the lock
+		werr := sleep(req.Context(), delay)
+		if werr == nil && atomic.LoadUint64(&m.generation) != generation {
+			werr = ErrReset
+		}
+		if werr != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, true, werr
+		}
+	}
+	return resp, true, err
+}
+
+// do serves the request of the given generation and returns the simulated
+// latency of the response.  As part of a chain, the request is served
+// regardless of its context and false is returned if the responder declines
+// the request.  A responder with a custom doFunc is assumed to be able to
+// serve any request.
+func (m *MockResponder) do(req *http.Request, generation uint64, chained bool) (*http.Response, bool, time.Duration, error) {
 	// one request at a time!
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.doFunc(req)
+	if m.closed {
+		if chained {
+			return nil, false, 0, nil
+		}
+		return nil, true, 0, ErrClosed
+	}
+	if atomic.LoadUint64(&m.generation) != generation {
+		return nil, true, 0, ErrReset
+	}
+	if chained {
+		req = m.attach(req)
+		if !m.customDo && !m.accepts(req) {
+			return nil, false, 0, nil
+		}
+	} else if !m.customDo && discover(req) == nil {
+		switch m.contextPolicy {
+		case ContextFallback:
+			req = m.attach(req)
+		case ContextError:
+			return nil, true, 0, ErrNoContext
+		}
+	}
+	var resp *http.Response
+	var err error
+	m.delay = 0
+	if m.customDo {
+		df := m.doFunc
+		what := func() string { return "custom do function" }
+		if gerr := m.guard(what, func() { resp, err = df(req) }); gerr != nil {
+			return nil, true, 0, gerr
+		}
+	} else {
+		resp, err = m.doFunc(req)
+	}
+	return resp, true, m.latencyFor(req) + m.delay, err
+}
+
+// accepts returns true if the responder serves the request as part of a
+// chain: a duplicate within the collapse window, a stored resource, an
+// unserved response or the API matches the request.  A response with an
+// invalid pattern accepts the request to report the misconfiguration.
+func (m *MockResponder) accepts(req *http.Request) bool {
+	// trailers are only available once the body was read
+	readBody(req)
+	if _, ok := m.collapse(req); ok {
+		return true
+	}
+	if m.hasResource(req) {
+		return true
+	}
+	for _, idx := range m.candidates(req) {
+		data := &m.mockData[idx]
+		if data.served {
+			continue
+		}
+		if m.patternError(data) != nil || m.mismatch(data, req) == "" {
+			return true
+		}
+	}
+	_, ok := m.apiRoute(req)
+	return ok
+}
+
+// RoundTrip satisfies the http.RoundTripper interface so that the responder
+// can be used as the Transport of an http.Client.  Requests are always served
+// by this responder, regardless of their context.
+func (m *MockResponder) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.Do(m.attach(req))
+}
+
+// Client returns an http.Client which uses the responder as its Transport.
+// This wires the responder into client libraries which accept an http.Client
+// or a Doer, like resty, heimdall or sling, without propagating the context of
+// the responder through the library.
+func (m *MockResponder) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// attach returns a shallow copy of the request with the responder stored in
+// its context.
+func (m *MockResponder) attach(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), contextMockClient, m))
+}
+
+// SetFailurePolicy defines how the responder fails, e.g. when it runs out of
+// data.  The default is to panic.
+func (m *MockResponder) SetFailurePolicy(policy FailurePolicy) {
+	m.mu.Lock()
+	m.failurePolicy = policy
+	m.mu.Unlock()
+}
+
+// SetMaxRequests limits the number of requests the responder accepts.  Any
+// request beyond the limit fails according to the failure policy, even if a
+// matching response exists.  This catches clients which issue duplicate
+// requests.  Zero means no limit.
+func (m *MockResponder) SetMaxRequests(n int) {
+	m.mu.Lock()
+	m.maxRequests = n
+	m.mu.Unlock()
+}
+
+// TotalRequests returns the number of requests the responder has received.
+func (m *MockResponder) TotalRequests() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requests
+}
+
+// SetContextPolicy defines how requests without the context returned by
+// NewMockResponder are handled.  The default is to panic.
+func (m *MockResponder) SetContextPolicy(policy ContextPolicy) {
+	m.mu.Lock()
+	m.contextPolicy = policy
+	m.mu.Unlock()
 }
 
 // SetDoFunc sets a new Do func which again must satisfy the http.Client.Do()
 // interface.  If not set, the defaultDoFunc() / built-in doFunc is used.
 func (m *MockResponder) SetDoFunc(df func(req *http.Request) (*http.Response, error)) {
+	m.mu.Lock()
 	m.doFunc = df
+	m.customDo = true
+	m.mu.Unlock()
+}
+
+// SetFallback sets a response which is served whenever neither an unserved
+// response nor the API, see SetAPI(), matches a request, instead of
+// panicking.  Requests answered by the fallback are recorded and can be
+// retrieved via Unexpected().  The fallback is not used when the responder is
+// part of a chain.
+func (m *MockResponder) SetFallback(fallback MockResp) {
+	m.mu.Lock()
+	m.fallback = &fallback
+	m.mu.Unlock()
+}
+
+// Unexpected returns the requests which did not match any of the mocked
+// responses and were answered by the fallback response.
+func (m *MockResponder) Unexpected() []CapturedRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CapturedRequest(nil), m.unexpected...)
+}
+
+// SetContentEncoding enables or disables encoding of the response bodies
+// according to the Accept-Encoding header of the request.  Supported encodings
+// are gzip, deflate and identity.  This allows to verify clients which handle
+// compressed responses themselves.
+func (m *MockResponder) SetContentEncoding(enabled bool) {
+	m.mu.Lock()
+	m.encodeBody = enabled
+	m.mu.Unlock()
 }
 
 // Reset resets the data of the responder so that it can be reused within the
-// same test.
+// same test.  It is the same as ResetServed.
 func (m *MockResponder) Reset() {
+	m.ResetServed()
+}
+
+// ResetServed marks all mocked responses as unserved so that they can be
+// served again.  The bookkeeping like the history and request counters are
+// kept.  Requests which are in flight while the responder is reset fail with
+// ErrReset instead of consuming responses of the new data set.
+func (m *MockResponder) ResetServed() {
 	m.mu.Lock()
+	m.resetServed()
+	m.mu.Unlock()
+}
+
+func (m *MockResponder) resetServed() {
 	for idx := range m.mockData {
 		m.mockData[idx].served = false
 	}
 	m.lastServed = 0
+	m.final = ""
+	m.collapsed = collapsed{}
+	atomic.AddUint64(&m.generation, 1)
+}
+
+// ResetAll marks all mocked responses as unserved and clears all bookkeeping:
+// the interaction history, unexpected requests, violations and request
+// counters.
+func (m *MockResponder) ResetAll() {
+	m.mu.Lock()
+	m.resetServed()
+	m.history = nil
+	m.seq = 0
+	m.dropped = 0
+	m.unexpected = nil
+	m.violations = nil
+	m.sequences = nil
+	m.resources = nil
+	m.pending = nil
+	m.lastResourceID = 0
+	m.lastRequest = time.Time{}
+	m.requests = 0
+	m.bodies = nil
+	m.undrained = nil
 	m.mu.Unlock()
 }
 
 // SetData sets a new mocked data response list into the mock responder.
+// Responses which are shadowed by earlier, broader responses are logged, see
+// MockRespList.Shadowed(), as are patterns which are likely to be slow, see
+// MockRespList.RiskyPatterns(), and responses with invalid patterns which are
+// served as a misconfiguration, see SetMisconfigurationResp().  The patterns
+// are compiled once and reused for all requests, matches which take unusually
+// long are logged.  Responses with conflicting fields are logged or, in strict
+// mode, rejected, see SetStrictValidation().
 func (m *MockResponder) SetData(data MockRespList) {
+	if conflicts := data.conflicts(); len(conflicts) > 0 {
+		if m.isStrict() {
+			panic("conflicting mocked responses: " + strings.Join(conflicts, "; "))
+		}
+		for _, c := range conflicts {
+			m.logf("warning: %s", c)
+		}
+	}
+	for _, w := range data.Shadowed() {
+		m.logf("warning: %s", w)
+	}
+	for _, w := range data.RiskyPatterns() {
+		m.logf("warning: %s", w)
+	}
+	for _, err := range m.register(data) {
+		m.logf("warning: %s", err)
+	}
+	for i := range data {
+		if len(data[i].DataFile) == 0 {
+			continue
+		}
+		if _, err := os.Stat(data[i].DataFile); err != nil {
+			m.logf("warning: response %d (%q): missing data file: %s", i, data[i].URL, err)
+		}
+	}
+	m.mu.Lock()
 	m.mockData = data
-	m.Reset()
+	m.index = nil
+	m.resetServed()
+	m.mu.Unlock()
+}
+
+// SetDataE is like SetData but validates the patterns of all responses first.
+// If any pattern is invalid or, in strict mode, any response has conflicting
+// fields, the data is not set and the returned error lists the problems.
+func (m *MockResponder) SetDataE(data MockRespList) error {
+	if m.isStrict() {
+		if conflicts := data.conflicts(); len(conflicts) > 0 {
+			return errors.New(strings.Join(conflicts, "; "))
+		}
+	}
+	if problems := m.register(data); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	m.SetData(data)
+	return nil
 }
 
-// GetData returns the currently set mocked data response list.
+// register prepares the responses for matching: it records the registration
+// time and precompiles the patterns.  It returns a problem for every response
+// with invalid patterns.
+func (m *MockResponder) register(data MockRespList) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	var problems []string
+	for i := range data {
+		if data[i].ValidFor > 0 {
+			data[i].registered = now
+		}
+		if err := m.patternError(&data[i]); err != nil {
+			problems = append(problems, fmt.Sprintf("response %d (%q): %s", i, data[i].URL, err))
+		}
+	}
+	return problems
+}
+
+// GetData returns a copy of the currently set mocked data response list.
+// Changing the copy doesn't affect the responder, use SetData instead.  The
+// copies share the bodies and headers with the responder which must not be
+// modified.
 func (m *MockResponder) GetData() MockRespList {
-	return m.mockData
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append(MockRespList(nil), m.mockData...)
+}
+
+// ForEachStub calls fn for every mocked response in order with its index until
+// fn returns false.  The responses are a snapshot taken before the first call,
+// fn may call the responder.
+func (m *MockResponder) ForEachStub(fn func(i int, r MockResp) bool) {
+	for i, r := range m.GetData() {
+		if !fn(i, r) {
+			return
+		}
+	}
 }
 
 // LastData retrieves the mocked data response which was last served.
 func (m *MockResponder) LastData() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.mockData[m.lastServed].Data
 }
 
@@ -178,15 +1016,103 @@ func (m *MockResponder) LastData() []byte {
 // This can be useful at the end of the test to ensure that all data has been
 // consumed which typically should be the case after a test run.
 func (m *MockResponder) Empty() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, d := range m.mockData {
 		if !d.served {
-			log.Println(d)
+			m.logf("%s", d)
 			return false
 		}
 	}
 	return true
 }
 
+// EmptyForTag returns true if all responses with the given tag have been
+// served, regardless of the responses with other tags.  This allows a subtest
+// to verify that the responses of its flow have been consumed.
+func (m *MockResponder) EmptyForTag(tag string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.mockData {
+		if d.Tag == tag && !d.served {
+			m.logf("%s", d)
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForServed blocks until the response with the given name has been served
+// or the context is done.  This allows tests to synchronize with client code
+// running in background goroutines without sleeping.
+func (m *MockResponder) WaitForServed(ctx context.Context, name string) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.mu.Lock()
+			m.servedCond.Broadcast()
+			m.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		known, served := false, false
+		for _, d := range m.mockData {
+			if d.Name == name {
+				known = true
+				served = served || d.served
+			}
+		}
+		if !known {
+			return fmt.Errorf("no response named %q", name)
+		}
+		if served {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		m.servedCond.Wait()
+	}
+}
+
+// Close stops all servers started by the responder, logs a report of unserved
+// responses and unexpected requests and rejects all further requests with
+// ErrClosed.  It is safe to call Close multiple times, e.g. via t.Cleanup.
+func (m *MockResponder) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.unregister()
+	servers := m.servers
+	m.servers = nil
+	unserved := 0
+	for _, d := range m.mockData {
+		if !d.served {
+			unserved++
+		}
+	}
+	unexpected := len(m.unexpected)
+	m.mu.Unlock()
+
+	// servers wait for active requests which need the lock
+	for _, s := range servers {
+		s.Close()
+	}
+	if unserved > 0 || unexpected > 0 {
+		m.logf("closed with %d unserved responses and %d unexpected requests", unserved, unexpected)
+	}
+	return nil
+}
+
 // NewMockResponder returns a new mock responder and the accompanying context.
 // During a request, the mock responder can be retrieved via the context key.
 func NewMockResponder() (*MockResponder, context.Context) {
@@ -194,5 +1120,36 @@ func NewMockResponder() (*MockResponder, context.Context) {
 		doFunc:   defaultDoFunc,
 		mockData: nil,
 	}
+	mc.servedCond = sync.NewCond(&mc.mu)
+	mc.initSeed()
+	mc.initProfile()
 	return mc, context.WithValue(context.TODO(), contextMockClient, mc)
 }
+
+// NewMockResponderT returns a new mock responder and the accompanying context
+// like NewMockResponder.  The responder logs via t.Logf so that the output is
+// attached to the owning test and only shown in verbose runs or when the test
+// fails.  Once the test has finished, recorded violations fail the test, see
+// Violations(), the seed is logged if the test failed, logging stops and the
+// responder is closed.
+func NewMockResponderT(t testing.TB) (*MockResponder, context.Context) {
+	mc, ctx := NewMockResponder()
+	mc.SetLogger(func(format string, args ...any) {
+		t.Helper()
+		t.Logf(format, args...)
+	})
+	t.Cleanup(func() {
+		mc.SetLogger(func(string, ...any) {})
+	})
+	t.Cleanup(func() {
+		mc.Close()
+	})
+	t.Cleanup(func() {
+		mc.AssertNoViolations(t)
+		if t.Failed() {
+			seed := mc.Seed()
+			mc.logf("mock responder seed %d, set %s=%d to reproduce", seed, SeedEnv, seed)
+		}
+	})
+	return mc, ctx
+}
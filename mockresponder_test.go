@@ -3,10 +3,14 @@ package mockresponder
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -43,6 +47,28 @@ func TestMockResponder_SetData(t *testing.T) {
 	assert.Equal(t, len(mrClient.mockData), 4)
 }
 
+func TestMockResponder_SetDataE(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	err := mrClient.SetDataE(MockRespList{
+		MockResp{URL: "ok$"},
+		MockResp{URL: "* * *"},
+		MockResp{MatchHeaders: map[string]string{"Authorization": "(Bearer"}},
+	})
+	assert.EqualError(t, err, `response 1 ("* * *"): invalid pattern "* * *": error parsing regexp: `+
+		"missing argument to repetition operator: `*`; "+
+		`response 2 (""): invalid pattern "(Bearer": error parsing regexp: missing closing ): `+"`(Bearer`")
+	assert.Empty(t, mrClient.GetData())
+
+	err = mrClient.SetDataE(MockRespList{MockResp{URL: "xml$", MatchXML: []byte(`<a><b></a>`)}})
+	assert.ErrorContains(t, err, `response 0 ("xml$"): invalid XML "<a><b></a>": `)
+	assert.Empty(t, mrClient.GetData())
+
+	assert.NoError(t, mrClient.SetDataE(MockRespList{MockResp{URL: "ok$"}, MockResp{BodyPattern: "^{}$"}}))
+	assert.Len(t, mrClient.GetData(), 2)
+	assert.Contains(t, mrClient.regexps, "ok$")
+	assert.Contains(t, mrClient.regexps, "^{}$")
+}
+
 func TestMockResponder_GetData(t *testing.T) {
 	mrClient, _ := NewMockResponder()
 	data := MockRespList{
@@ -54,6 +80,26 @@ func TestMockResponder_GetData(t *testing.T) {
 	mrClient.SetData(data)
 	newData := mrClient.GetData()
 	assert.Equal(t, data, newData)
+
+	// the copy doesn't change the responder
+	newData[0].Code = 500
+	assert.Equal(t, 200, mrClient.GetData()[0].Code)
+}
+
+func TestMockResponder_ForEachStub(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Code: 200},
+		MockResp{Code: 204},
+		MockResp{Code: 401},
+	})
+	var codes []int
+	mrClient.ForEachStub(func(i int, r MockResp) bool {
+		assert.Len(t, mrClient.GetData(), 3)
+		codes = append(codes, r.Code)
+		return i < 1
+	})
+	assert.Equal(t, []int{200, 204}, codes)
 }
 
 func TestMockResponder_Empty(t *testing.T) {
@@ -73,6 +119,35 @@ func TestMockResponder_Empty(t *testing.T) {
 	}
 }
 
+func TestMockResponder_ResetAll(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{}})
+	mrClient.SetFallback(MockResp{})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	for i := 0; i < 2; i++ {
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.True(t, mrClient.Empty())
+
+	mrClient.ResetServed()
+	assert.False(t, mrClient.Empty())
+	assert.Equal(t, 2, mrClient.TotalRequests())
+	assert.Len(t, mrClient.History(), 2)
+	assert.Len(t, mrClient.Unexpected(), 1)
+
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	mrClient.ResetAll()
+	assert.False(t, mrClient.Empty())
+	assert.Zero(t, mrClient.TotalRequests())
+	assert.Empty(t, mrClient.History())
+	assert.Empty(t, mrClient.Unexpected())
+}
+
 func TestMockResponder_LastData(t *testing.T) {
 	mrClient, _ := NewMockResponder()
 	data := MockRespList{
@@ -144,9 +219,12 @@ func TestMockResponder_PanicsInDo(t *testing.T) {
 	}
 	assert.Panics(t, panicFunc)
 
-	// this panics because of the invalid regex set above
+	// the invalid regex set above is served as a misconfiguration
 	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "", nil)
-	assert.Panics(t, panicFunc)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 
 	// this has the correct context key but the value is not a MockResponder
 	bogusCtx := context.WithValue(context.TODO(), contextMockClient, data)
@@ -166,6 +244,114 @@ func TestMockResponder_PanicsInDo(t *testing.T) {
 
 }
 
+func TestMockResponder_WaitForServed(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	data := MockRespList{
+		MockResp{Name: "first"},
+		MockResp{Name: "second"},
+	}
+	mrClient.SetData(data)
+
+	assert.EqualError(t, mrClient.WaitForServed(ctx, "third"), `no response named "third"`)
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+			resp, _ := mrClient.Do(req)
+			resp.Body.Close()
+		}
+	}()
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	assert.NoError(t, mrClient.WaitForServed(waitCtx, "second"))
+	assert.NoError(t, mrClient.WaitForServed(waitCtx, "first"))
+
+	mrClient.Reset()
+	waitCtx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	assert.ErrorIs(t, mrClient.WaitForServed(waitCtx, "first"), context.DeadlineExceeded)
+}
+
+func TestMockResponder_SetContextPolicy(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`OK`)},
+		MockResp{Data: []byte(`OK`)},
+	})
+
+	req, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, "/bla", nil)
+	assert.PanicsWithValue(t, noContextHint, func() { mrClient.Do(req) })
+
+	mrClient.SetContextPolicy(ContextError)
+	_, err := mrClient.Do(req)
+	assert.ErrorIs(t, err, ErrNoContext)
+
+	mrClient.SetContextPolicy(ContextFallback)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	// as Transport, the responder never needs the context
+	mrClient.SetContextPolicy(ContextPanic)
+	client := &http.Client{Transport: mrClient}
+	resp, err = client.Get("http://bla/bla")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.True(t, mrClient.Empty())
+}
+
+func TestMockResponder_SetMaxRequests(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}, MockResp{}})
+	mrClient.SetMaxRequests(2)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	for i := 0; i < 2; i++ {
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 2, mrClient.TotalRequests())
+	assert.PanicsWithValue(t, "too many requests: request 3 exceeds the limit of 2", func() { mrClient.Do(req) })
+
+	mrClient.SetFailurePolicy(FailError)
+	_, err := mrClient.Do(req)
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+	assert.Equal(t, 4, mrClient.TotalRequests())
+
+	mrClient.SetMaxRequests(0)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	_, err = mrClient.Do(req)
+	assert.ErrorIs(t, err, ErrOutOfData)
+}
+
+func TestMockResponder_Close(t *testing.T) {
+	var lines []string
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}})
+	s := mrClient.Server()
+
+	resp, err := s.Client().Get(s.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.NoError(t, mrClient.Close())
+	assert.NoError(t, mrClient.Close())
+	assert.Equal(t, "closed with 1 unserved responses and 0 unexpected requests", lines[len(lines)-1])
+
+	_, err = s.Client().Get(s.URL)
+	assert.Error(t, err)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	_, err = mrClient.Do(req)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
 func Test_sanitizeURL(t *testing.T) {
 	tests := []struct {
 		name string
@@ -234,3 +420,189 @@ func Test_Race(t *testing.T) {
 	assert.Eventually(t, doneCheck, time.Second*5, time.Microsecond*50)
 	assert.True(t, mrClient.Empty())
 }
+
+func TestMockResponder_Client(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{Data: []byte(`OK`)}})
+
+	// libraries like sling accept any Doer
+	var doer Doer = mrClient.Client()
+	req, _ := http.NewRequest(http.MethodGet, "http://api.example.com/bla", nil)
+	resp, err := doer.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, []byte(`OK`), body)
+}
+
+func TestMockResp_Delay(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/slow$", Delay: 50 * time.Millisecond, Data: []byte(`slow`)},
+		MockResp{URL: "/slow$", Delay: time.Hour},
+		MockResp{URL: "/fast$"},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/slow", nil)
+	start := time.Now()
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, []byte(`slow`), body)
+
+	// the client gives up before the response is delivered
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(tctx, http.MethodGet, "/slow", nil)
+	_, err = mrClient.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the delay applies to its response only
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "/fast", nil)
+	start = time.Now()
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestMockResp_DataFunc(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	echo := func(req *http.Request) []byte {
+		return []byte(`{"cursor": "` + req.URL.Query().Get("after") + `-next"}`)
+	}
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/items", DataFunc: echo},
+		MockResp{URL: "/items", DataFunc: echo},
+	})
+
+	for _, after := range []string{"a", "b"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/items?after="+after, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.JSONEq(t, `{"cursor": "`+after+`-next"}`, string(body))
+	}
+
+	// a hanging function fails the request
+	release := make(chan struct{})
+	defer close(release)
+	mrClient.SetHookTimeout(20 * time.Millisecond)
+	mrClient.SetData(MockRespList{MockResp{Name: "slow", DataFunc: func(*http.Request) []byte {
+		<-release
+		return nil
+	}}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/items", nil)
+	_, err := mrClient.Do(req)
+	assert.ErrorIs(t, err, ErrHookTimeout)
+	assert.EqualError(t, err, `hook timed out: data function of response ("slow", "") did not return within 20ms`)
+
+	assert.EqualError(t, MockResp{DataFunc: echo, Data: []byte(`static`)}.Validate(),
+		"data function (DataFunc) is ambiguous with response fields Data")
+}
+
+func TestMockResp_Respond(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/static$", Data: []byte(`static`)},
+		MockResp{URL: "/dynamic$", Respond: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusAccepted,
+				Header:     http.Header{"X-Method": []string{req.Method}},
+				Body:       io.NopCloser(strings.NewReader(`dynamic`)),
+			}, nil
+		}},
+		MockResp{URL: "/broken$", Respond: func(*http.Request) (*http.Response, error) {
+			return nil, nil
+		}},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/dynamic", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, http.MethodPost, resp.Header.Get("X-Method"))
+	assert.Equal(t, []byte(`dynamic`), body)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "/broken", nil)
+	_, err = mrClient.Do(req)
+	assert.EqualError(t, err, `respond function of response ("", "/broken$") returned neither a response nor an error`)
+
+	// the other responses are served as usual
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "/static", nil)
+	resp, err = mrClient.Do(req)
+	assert.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, []byte(`static`), body)
+
+	respond := func(*http.Request) (*http.Response, error) { return nil, nil }
+	assert.EqualError(t, MockResp{Respond: respond, Code: http.StatusOK}.Validate(),
+		"respond function (Respond) is ambiguous with response fields Code")
+}
+
+func TestMockResp_Respond_Headers(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetStubHeader(true)
+	mrClient.SetChecksums(ChecksumMD5)
+	mrClient.SetData(MockRespList{MockResp{
+		Name:          "dynamic",
+		Header:        http.Header{"X-Mocked": []string{"yes"}},
+		Informational: []Informational{EarlyHints("</style.css>; rel=preload")},
+		Respond: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"X-Built": []string{"yes"}},
+				Body:       io.NopCloser(strings.NewReader(`dynamic`)),
+			}, nil
+		},
+	}})
+
+	var codes []int
+	trace := &httptrace.ClientTrace{Got1xxResponse: func(code int, _ textproto.MIMEHeader) error {
+		codes = append(codes, code)
+		return nil
+	}}
+	req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, "/dynamic", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{http.StatusEarlyHints}, codes)
+	assert.Equal(t, "dynamic/0", resp.Header.Get(StubHeader))
+	assert.Equal(t, "yes", resp.Header.Get("X-Mocked"))
+	assert.Equal(t, "yes", resp.Header.Get("X-Built"))
+	assert.NotEmpty(t, resp.Header.Get("Date"))
+	assert.Equal(t, "ty8705G6cxo1cIv9jNimjw==", resp.Header.Get(string(ChecksumMD5)))
+
+	// the body is tracked
+	assert.False(t, mrClient.AssertBodiesClosed(&mockT{}))
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, []byte(`dynamic`), body)
+	assert.True(t, mrClient.AssertBodiesClosed(t))
+}
+
+func TestMockResponder_ResetInFlight(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{Data: []byte(`old`)}})
+	mrClient.SetLatency(100 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+		_, err := mrClient.Do(req)
+		done <- err
+	}()
+	assert.Eventually(t, func() bool { return mrClient.TotalRequests() == 1 }, time.Second, time.Millisecond)
+	mrClient.SetData(MockRespList{MockResp{Data: []byte(`new`)}})
+	assert.ErrorIs(t, <-done, ErrReset)
+
+	// a request of an older generation doesn't consume new responses
+	_, _, _, err := mrClient.do(nil, 0, false)
+	assert.ErrorIs(t, err, ErrReset)
+	assert.False(t, mrClient.Empty())
+}
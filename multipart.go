@@ -0,0 +1,93 @@
+package mockresponder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+)
+
+// MultipartMatch restricts a mocked response to multipart/form-data requests
+// with the given parts, see MockResp.MatchMultipart.  Fields maps the names of
+// form fields to regular expressions which their values must match, Files
+// lists the names of parts which must carry a file and ContentTypes maps the
+// names of parts to the media type they must have, e.g. "image/png".  Other
+// parts are ignored.
+type MultipartMatch struct {
+	Fields       map[string]string
+	Files        []string
+	ContentTypes map[string]string
+}
+
+// formPart is a part of a multipart/form-data body.
+type formPart struct {
+	fileName    string
+	contentType string
+	value       []byte
+}
+
+// parseMultipart returns the parts of the multipart/form-data body of the
+// request by name.
+func parseMultipart(req *http.Request) (map[string]formPart, error) {
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, errors.New("body is not multipart/form-data")
+	}
+	r := multipart.NewReader(bytes.NewReader(payload(req)), params["boundary"])
+	parts := make(map[string]formPart)
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid multipart body: %w", err)
+		}
+		value, err := io.ReadAll(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multipart body: %w", err)
+		}
+		parts[p.FormName()] = formPart{
+			fileName:    p.FileName(),
+			contentType: p.Header.Get("Content-Type"),
+			value:       value,
+		}
+	}
+}
+
+// mismatchMultipart checks the parts of the multipart/form-data body of the
+// request.
+func (m *MockResponder) mismatchMultipart(want *MultipartMatch, req *http.Request) string {
+	parts, err := parseMultipart(req)
+	if err != nil {
+		return err.Error()
+	}
+	for name, pattern := range want.Fields {
+		p, ok := parts[name]
+		switch {
+		case !ok:
+			return fmt.Sprintf("form field %q is missing", name)
+		case !m.mustCompile(pattern).Match(p.value):
+			return fmt.Sprintf("form field %q %q does not match %q", name, p.value, pattern)
+		}
+	}
+	for _, name := range want.Files {
+		if p, ok := parts[name]; !ok || len(p.fileName) == 0 {
+			return fmt.Sprintf("file part %q is missing", name)
+		}
+	}
+	for name, ct := range want.ContentTypes {
+		p, ok := parts[name]
+		if !ok {
+			return fmt.Sprintf("part %q is missing", name)
+		}
+		got, _, err := mime.ParseMediaType(p.contentType)
+		if err != nil || got != ct {
+			return fmt.Sprintf("part %q has content type %q, want %q", name, p.contentType, ct)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package mockresponder
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_MatchMultipart(t *testing.T) {
+	upload := func(withFile bool) *http.Request {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		_ = w.WriteField("name", "router-1")
+		if withFile {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", `form-data; name="image"; filename="r1.png"`)
+			h.Set("Content-Type", "image/png")
+			p, _ := w.CreatePart(h)
+			_, _ = p.Write([]byte("\x89PNG"))
+		}
+		w.Close()
+		req, _ := http.NewRequest(http.MethodPost, "/upload", &buf)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{MatchMultipart: &MultipartMatch{
+			Fields:       map[string]string{"name": "^router-"},
+			Files:        []string{"image"},
+			ContentTypes: map[string]string{"image": "image/png"},
+		}, Code: http.StatusCreated},
+		MockResp{MatchMultipart: &MultipartMatch{Fields: map[string]string{"name": "."}}, Code: http.StatusBadRequest},
+	})
+	req := upload(false)
+	resp, err := mrClient.Do(req.WithContext(ctx))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	req = upload(true)
+	resp, err = mrClient.Do(req.WithContext(ctx))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	tests := []struct {
+		want   MultipartMatch
+		reason string
+	}{
+		{MultipartMatch{Fields: map[string]string{"kind": "."}}, `form field "kind" is missing`},
+		{MultipartMatch{Fields: map[string]string{"name": "^switch"}}, `form field "name" "router-1" does not match "^switch"`},
+		{MultipartMatch{Files: []string{"name"}}, `file part "name" is missing`},
+		{MultipartMatch{ContentTypes: map[string]string{"image": "image/jpeg"}}, `part "image" has content type "image/png", want "image/jpeg"`},
+		{MultipartMatch{ContentTypes: map[string]string{"video": "video/mp4"}}, `part "video" is missing`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.reason, mrClient.mismatch(&MockResp{MatchMultipart: &tt.want}, upload(true)))
+	}
+	req, _ = http.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{}`))
+	assert.Equal(t, "body is not multipart/form-data", mrClient.mismatch(&MockResp{MatchMultipart: &MultipartMatch{}}, req))
+}
@@ -0,0 +1,81 @@
+package mockresponder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Mutation is a mutated status code of a single mocked response.
+type Mutation struct {
+	Index int
+	Name  string
+	URL   string
+	From  int
+	To    int
+}
+
+func (m Mutation) String() string {
+	return fmt.Sprintf("response %d (%q %q): %d -> %d", m.Index, m.Name, m.URL, m.From, m.To)
+}
+
+// mutate returns the mutated status code for the given code: successful
+// responses become server errors and errors become successful responses.
+func mutate(code int) int {
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if code >= http.StatusBadRequest {
+		return http.StatusOK
+	}
+	return http.StatusInternalServerError
+}
+
+// MutationTest is a lightweight mutation test of the HTTP handling of a client.
+// The run function exercises the client with the given responder and context
+// and must return an error if the client detected a problem.  It is called
+// once with the unmodified data, which must succeed, and then once per mocked
+// response with the status code of only that response mutated (e.g. 200 to 500
+// or 404 to 200).  Transport errors, raw responses and responses built by a
+// Respond function are not mutated as they don't use the status code.  The
+// responders use the FailError failure policy and are closed after each run.
+// As the data is served once per run, responses with a BodyReader, which can
+// only be read once, are rejected, use Data or DataFile instead.
+//
+// The returned mutations are those which the client did not detect, i.e.
+// where run succeeded nonetheless.
+func MutationTest(data MockRespList, run func(ctx context.Context, mr *MockResponder) error) ([]Mutation, error) {
+	for i, d := range data {
+		if d.BodyReader != nil {
+			return nil, fmt.Errorf("response %d (%q): a body reader can't be served in several runs", i, d.URL)
+		}
+	}
+	exec := func(data MockRespList) error {
+		mr, ctx := NewMockResponder()
+		defer mr.Close()
+		mr.SetFailurePolicy(FailError)
+		mr.SetData(data)
+		return run(ctx, mr)
+	}
+
+	if err := exec(append(MockRespList(nil), data...)); err != nil {
+		return nil, fmt.Errorf("unmutated run failed: %w", err)
+	}
+
+	var survived []Mutation
+	for i, d := range data {
+		if d.Err != nil || len(d.Raw) > 0 || d.Respond != nil {
+			continue
+		}
+		mutation := Mutation{Index: i, Name: d.Name, URL: d.URL, From: d.Code, To: mutate(d.Code)}
+		if mutation.From == 0 {
+			mutation.From = http.StatusOK
+		}
+		mutated := append(MockRespList(nil), data...)
+		mutated[i].Code = mutation.To
+		if exec(mutated) == nil {
+			survived = append(survived, mutation)
+		}
+	}
+	return survived, nil
+}
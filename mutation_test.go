@@ -0,0 +1,87 @@
+package mockresponder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutationTest(t *testing.T) {
+	data := MockRespList{
+		MockResp{Name: "auth", URL: "/auth$"},
+		MockResp{Name: "version", URL: "/version$"},
+		MockResp{Name: "missing", URL: "/missing$", Code: http.StatusNotFound},
+		MockResp{Name: "logout", URL: "/logout$", Err: errors.New("ka-boom")},
+		MockResp{Name: "raw", URL: "/raw$", Raw: []byte("HTTP/1.1 204 No Content\r\n\r\n")},
+		MockResp{Name: "respond", URL: "/respond$", Respond: func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+		}},
+	}
+
+	get := func(ctx context.Context, mr *MockResponder, path string) (int, error) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		resp, err := mr.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	// this client ignores the status of the version check
+	client := func(ctx context.Context, mr *MockResponder) error {
+		if code, err := get(ctx, mr, "/auth"); err != nil || code != http.StatusOK {
+			return fmt.Errorf("auth failed: %d %v", code, err)
+		}
+		get(ctx, mr, "/version")
+		if code, err := get(ctx, mr, "/missing"); err != nil || code != http.StatusNotFound {
+			return fmt.Errorf("missing exists: %d %v", code, err)
+		}
+		get(ctx, mr, "/logout")
+		get(ctx, mr, "/raw")
+		get(ctx, mr, "/respond")
+		return nil
+	}
+
+	survived, err := MutationTest(data, client)
+	assert.NoError(t, err)
+	assert.Equal(t, []Mutation{
+		{Index: 1, Name: "version", URL: "/version$", From: http.StatusOK, To: http.StatusInternalServerError},
+	}, survived)
+	assert.Equal(t, `response 1 ("version" "/version$"): 200 -> 500`, survived[0].String())
+	assert.Equal(t, 0, data[1].Code, "data must not be modified")
+
+	_, err = MutationTest(data, func(ctx context.Context, mr *MockResponder) error {
+		return errors.New("broken")
+	})
+	assert.EqualError(t, err, "unmutated run failed: broken")
+
+	runs := 0
+	_, err = MutationTest(MockRespList{
+		MockResp{URL: "/a$"},
+		MockResp{URL: "/stream$", BodyReader: strings.NewReader("chunk")},
+	}, func(ctx context.Context, mr *MockResponder) error {
+		runs++
+		return nil
+	})
+	assert.EqualError(t, err, `response 1 ("/stream$"): a body reader can't be served in several runs`)
+	assert.Zero(t, runs)
+
+	// the responders of the runs are closed
+	var responders []*MockResponder
+	_, err = MutationTest(data[:1], func(ctx context.Context, mr *MockResponder) error {
+		responders = append(responders, mr)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, responders, 2)
+	for _, mr := range responders {
+		_, err := mr.Do(nil)
+		assert.ErrorIs(t, err, ErrClosed)
+	}
+}
@@ -0,0 +1,51 @@
+package mockresponder
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// outage is a time window in which all requests fail with 503.
+type outage struct {
+	from, until time.Time
+}
+
+// ScheduleOutage schedules a simulated upstream outage which starts after the
+// given delay, relative to the current time of the responder's clock, and
+// lasts for the given duration.  During the outage every request is answered
+// with 503 Service Unavailable and a Retry-After header, without consuming any
+// mocked response.  Afterwards the responder recovers.
+func (m *MockResponder) ScheduleOutage(after, duration time.Duration) {
+	m.mu.Lock()
+	from := m.now().Add(after)
+	m.outages = append(m.outages, outage{from: from, until: from.Add(duration)})
+	m.mu.Unlock()
+}
+
+// ClearOutages removes all scheduled outages.
+func (m *MockResponder) ClearOutages() {
+	m.mu.Lock()
+	m.outages = nil
+	m.mu.Unlock()
+}
+
+// outageResp returns the response to serve if an outage is active.
+func (m *MockResponder) outageResp() (MockResp, bool) {
+	now := m.now()
+	for _, o := range m.outages {
+		if now.Before(o.from) || !now.Before(o.until) {
+			continue
+		}
+		retry := int(o.until.Sub(now).Round(time.Second) / time.Second)
+		if retry < 1 {
+			retry = 1
+		}
+		return MockResp{
+			Code:   http.StatusServiceUnavailable,
+			Data:   []byte("service unavailable"),
+			Header: http.Header{"Retry-After": []string{strconv.Itoa(retry)}},
+		}, true
+	}
+	return MockResp{}, false
+}
@@ -0,0 +1,43 @@
+package mockresponder
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_ScheduleOutage(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	now := time.Now()
+	mrClient.SetClock(func() time.Time { return now })
+	mrClient.SetData(MockRespList{MockResp{URL: "/a$"}, MockResp{URL: "/b$"}})
+	mrClient.ScheduleOutage(time.Second, time.Minute)
+
+	get := func(path string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	assert.Equal(t, http.StatusOK, get("/a").StatusCode)
+	now = now.Add(time.Second * 30)
+	for _, path := range []string{"/b", "/c"} {
+		resp := get(path)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "31", resp.Header.Get("Retry-After"))
+	}
+	assert.False(t, mrClient.Empty())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, http.StatusOK, get("/b").StatusCode)
+	assert.True(t, mrClient.Empty())
+
+	mrClient.ScheduleOutage(0, time.Hour)
+	mrClient.ClearOutages()
+	mrClient.SetData(MockRespList{MockResp{}})
+	assert.Equal(t, http.StatusOK, get("/a").StatusCode)
+}
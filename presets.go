@@ -0,0 +1,79 @@
+package mockresponder
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The presets return fragments of mocked responses for common patterns which
+// are composed via Concat, e.g.
+//
+//	data := Concat(
+//		MaintenanceMode("/status$", 2, time.Second),
+//		HealthyService("/status$", 1),
+//	)
+
+// Concat returns the fragments of mocked responses joined in order.
+func Concat(fragments ...MockRespList) MockRespList {
+	var list MockRespList
+	for _, f := range fragments {
+		list = append(list, f...)
+	}
+	return list
+}
+
+// HealthyService returns n successful JSON responses with the body
+// {"status": "ok"} for requests matching url.
+func HealthyService(url string, n int) MockRespList {
+	list := make(MockRespList, 0, n)
+	for i := 0; i < n; i++ {
+		list = append(list, MockResp{
+			URL:    url,
+			Data:   []byte(`{"status": "ok"}`),
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+		})
+	}
+	return list
+}
+
+// AuthExpiredThenRefresh returns the responses of an expired access token: the
+// request matching url fails with 401 Unauthorized, the following request
+// matching tokenURL returns a new OAuth 2.0 access token and the retried
+// request matching url succeeds with data.
+func AuthExpiredThenRefresh(url, tokenURL string, data []byte) MockRespList {
+	return MockRespList{
+		{
+			URL:  url,
+			Code: http.StatusUnauthorized,
+			Header: http.Header{"Www-Authenticate": []string{
+				`Bearer error="invalid_token", error_description="The access token expired"`,
+			}},
+		},
+		{
+			URL:    tokenURL,
+			Data:   []byte(`{"access_token": "refreshed-token", "token_type": "Bearer", "expires_in": 3600}`),
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+		},
+		{URL: url, Data: data},
+	}
+}
+
+// MaintenanceMode returns n responses of a service in maintenance for requests
+// matching url: 503 Service Unavailable with the Retry-After header set to
+// retryAfter, in seconds.
+func MaintenanceMode(url string, n int, retryAfter time.Duration) MockRespList {
+	list := make(MockRespList, 0, n)
+	for i := 0; i < n; i++ {
+		list = append(list, MockResp{
+			URL:  url,
+			Code: http.StatusServiceUnavailable,
+			Data: []byte("service in maintenance"),
+			Header: http.Header{
+				"Retry-After":  []string{strconv.Itoa(int(retryAfter / time.Second))},
+				"Content-Type": []string{"text/plain; charset=utf-8"},
+			},
+		})
+	}
+	return list
+}
@@ -0,0 +1,46 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresets(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	data := Concat(
+		MaintenanceMode("/status$", 2, 30*time.Second),
+		HealthyService("/status$", 1),
+		AuthExpiredThenRefresh("/devices$", "/oauth/token$", []byte(`[]`)),
+	)
+	assert.Len(t, data, 6)
+	assert.NoError(t, data.Validate())
+	mrClient.SetData(data)
+
+	get := func(url string) (int, string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+		}
+		return resp.StatusCode, string(body)
+	}
+	for _, want := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK} {
+		code, _ := get("/status")
+		assert.Equal(t, want, code)
+	}
+	code, _ := get("/devices")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	_, body := get("/oauth/token")
+	assert.Contains(t, body, "refreshed-token")
+	code, body = get("/devices")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "[]", body)
+	assert.True(t, mrClient.Empty())
+}
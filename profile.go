@@ -0,0 +1,183 @@
+package mockresponder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables which override the network profile of new responders
+// so that the same tests can be run in e.g. a "slow network" profile in CI
+// without code changes.
+const (
+	// LatencyEnv sets the base latency of every response, e.g. "50ms".
+	LatencyEnv = "MOCKRESPONDER_LATENCY"
+	// LatencyScaleEnv sets the multiplier of the simulated latency, e.g.
+	// "3".
+	LatencyScaleEnv = "MOCKRESPONDER_LATENCY_SCALE"
+	// FailRateEnv sets the fraction of requests which fail, e.g. "0.05".
+	FailRateEnv = "MOCKRESPONDER_FAIL_RATE"
+)
+
+// initProfile sets the network profile from the environment.
+func (m *MockResponder) initProfile() {
+	m.latencyScale = 1
+	if v := os.Getenv(LatencyEnv); len(v) > 0 {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			m.logf("warning: ignoring invalid %s %q", LatencyEnv, v)
+		} else {
+			m.latencyBase = d
+		}
+	}
+	if v := os.Getenv(LatencyScaleEnv); len(v) > 0 {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			m.logf("warning: ignoring invalid %s %q", LatencyScaleEnv, v)
+		} else {
+			m.latencyScale = f
+		}
+	}
+	if v := os.Getenv(FailRateEnv); len(v) > 0 {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			m.logf("warning: ignoring invalid %s %q", FailRateEnv, v)
+		} else {
+			m.failRate = f
+		}
+	}
+}
+
+// SetLatency sets the base latency of every response, by default LatencyEnv.
+func (m *MockResponder) SetLatency(d time.Duration) {
+	m.mu.Lock()
+	m.latencyBase = d
+	m.mu.Unlock()
+}
+
+// SetLatencyScale sets the multiplier of the simulated latency, by default
+// LatencyScaleEnv or 1.  It scales the base latency and the latencies of the
+// latency profiles, not the Delay of the responses.
+func (m *MockResponder) SetLatencyScale(scale float64) {
+	m.mu.Lock()
+	m.latencyScale = scale
+	m.mu.Unlock()
+}
+
+// SetFailRate sets the fraction of requests, between 0 and 1, which fail with
+// the transport error ErrInjected instead of being served, by default
+// FailRateEnv.  The failing requests are chosen based on the seed of the
+// responder, see SetSeed.
+func (m *MockResponder) SetFailRate(rate float64) {
+	m.mu.Lock()
+	m.failRate = rate
+	m.mu.Unlock()
+}
+
+// LatencyProfile assigns simulated latencies to the requests whose URL matches
+// the RegEx URL, e.g. "/search" is slow while "/health" is instant.  The
+// latency of a request is chosen from Latencies with a probability
+// proportional to its Weight, e.g. 95% 10ms and 5% 2s to model a long tail.
+type LatencyProfile struct {
+	URL       string
+	Latencies []WeightedLatency
+}
+
+// WeightedLatency is a latency of a LatencyProfile with its relative weight.
+type WeightedLatency struct {
+	Latency time.Duration
+	Weight  float64
+}
+
+// SetLatencyProfiles sets the latency profiles which override the base
+// latency, see SetLatency, for all responses regardless of the mocked response
+// which serves the request.  The first profile which matches the URL of a
+// request applies, URLs are matched like the URLs of mocked responses, see
+// SetMatchPathOnly.  The latencies are scaled, see SetLatencyScale, and chosen
+// based on the seed of the responder, see SetSeed.  An error is returned if a
+// URL is not a valid RegEx or a weight is negative, in this case no profile is
+// set.
+func (m *MockResponder) SetLatencyProfiles(profiles ...LatencyProfile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range profiles {
+		if _, err := m.compile(p.URL); err != nil {
+			return fmt.Errorf("latency profile %d: %w", i, err)
+		}
+		for _, l := range p.Latencies {
+			if l.Weight < 0 || l.Latency < 0 {
+				return fmt.Errorf("latency profile %d: negative latency or weight", i)
+			}
+		}
+	}
+	m.latencyProfiles = append([]LatencyProfile(nil), profiles...)
+	return nil
+}
+
+// latency returns the simulated latency of a response.
+func (m *MockResponder) latency() time.Duration {
+	return time.Duration(float64(m.latencyBase) * m.latencyScale)
+}
+
+// latencyFor returns the simulated latency of the response to the request,
+// taking the latency profiles into account.
+func (m *MockResponder) latencyFor(req *http.Request) time.Duration {
+	target := m.urlTarget(req)
+	for _, p := range m.latencyProfiles {
+		if m.mustCompile(p.URL).MatchString(target) {
+			return time.Duration(float64(m.pickLatency(p.Latencies)) * m.latencyScale)
+		}
+	}
+	return m.latency()
+}
+
+// delayFor returns the delay of the response, the fixed Delay plus a random
+// delay between DelayMin and DelayMax.
+func (m *MockResponder) delayFor(data MockResp) time.Duration {
+	delay := data.Delay
+	if data.DelayMax > data.DelayMin {
+		delay += data.DelayMin + time.Duration(m.rng.Int63n(int64(data.DelayMax-data.DelayMin)))
+	} else {
+		delay += data.DelayMax
+	}
+	return delay
+}
+
+// pickLatency returns one of the latencies chosen by weight.
+func (m *MockResponder) pickLatency(latencies []WeightedLatency) time.Duration {
+	total := 0.0
+	for _, l := range latencies {
+		total += l.Weight
+	}
+	if total == 0 {
+		return 0
+	}
+	r := m.rng.Float64() * total
+	for _, l := range latencies {
+		if r < l.Weight {
+			return l.Latency
+		}
+		r -= l.Weight
+	}
+	return latencies[len(latencies)-1].Latency
+}
+
+// injectFailure returns true if the request should fail.
+func (m *MockResponder) injectFailure() bool {
+	return m.failRate > 0 && m.rng.Float64() < m.failRate
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
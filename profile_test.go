@@ -0,0 +1,129 @@
+package mockresponder
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_Profile(t *testing.T) {
+	t.Setenv(LatencyEnv, "20ms")
+	t.Setenv(LatencyScaleEnv, "2")
+	t.Setenv(FailRateEnv, "1.5")
+	mrClient, ctx := NewMockResponder()
+	assert.Equal(t, 40*time.Millisecond, mrClient.latency())
+	assert.Zero(t, mrClient.failRate)
+
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}})
+	start := time.Now()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// the latency respects the request context
+	mrClient.SetLatency(time.Hour)
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(short, http.MethodGet, "/bla", nil)
+	_, err = mrClient.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMockResponder_SetFailRate(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetSeed(1)
+	mrClient.SetFailRate(0.5)
+	mrClient.SetFallback(MockResp{})
+
+	failed := 0
+	for i := 0; i < 100; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+		resp, err := mrClient.Do(req)
+		if err != nil {
+			assert.ErrorIs(t, err, ErrInjected)
+			failed++
+			continue
+		}
+		resp.Body.Close()
+	}
+	assert.InDelta(t, 50, failed, 20)
+	assert.Len(t, mrClient.History(), 100)
+
+	// the same seed fails the same requests
+	again, _ := NewMockResponder()
+	again.SetSeed(1)
+	again.SetFailRate(0.5)
+	count := 0
+	for i := 0; i < 100; i++ {
+		if again.injectFailure() {
+			count++
+		}
+	}
+	assert.Equal(t, failed, count)
+}
+
+func TestMockResponder_SetLatencyProfiles(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetSeed(1)
+	mrClient.SetLatency(5 * time.Millisecond)
+	mrClient.SetLatencyScale(2)
+	assert.NoError(t, mrClient.SetLatencyProfiles(
+		LatencyProfile{URL: "/health$"},
+		LatencyProfile{URL: "/search", Latencies: []WeightedLatency{
+			{Latency: 10 * time.Millisecond, Weight: 3},
+			{Latency: time.Second, Weight: 1},
+		}},
+	))
+
+	latency := func(url string) time.Duration {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		return mrClient.latencyFor(req)
+	}
+	assert.Zero(t, latency("/health"))
+	assert.Equal(t, 10*time.Millisecond, latency("/devices"))
+	counts := make(map[time.Duration]int)
+	for i := 0; i < 1000; i++ {
+		counts[latency("/search?q=x")]++
+	}
+	assert.Len(t, counts, 2)
+	assert.InDelta(t, 750, counts[20*time.Millisecond], 60)
+	assert.InDelta(t, 250, counts[2*time.Second], 60)
+
+	assert.ErrorContains(t, mrClient.SetLatencyProfiles(LatencyProfile{URL: "("}), "latency profile 0")
+	assert.ErrorContains(t, mrClient.SetLatencyProfiles(LatencyProfile{
+		Latencies: []WeightedLatency{{Weight: -1}},
+	}), "negative")
+	assert.Zero(t, latency("/health"))
+}
+
+func TestMockResponder_delayFor(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	data := MockResp{Delay: time.Second, DelayMin: 10 * time.Millisecond, DelayMax: 20 * time.Millisecond}
+
+	delays := func() []time.Duration {
+		var delays []time.Duration
+		for i := 0; i < 100; i++ {
+			delays = append(delays, mrClient.delayFor(data))
+		}
+		return delays
+	}
+	mrClient.SetSeed(1)
+	first := delays()
+	for _, d := range first {
+		assert.GreaterOrEqual(t, d, time.Second+10*time.Millisecond)
+		assert.Less(t, d, time.Second+20*time.Millisecond)
+	}
+	assert.NotEqual(t, first[0], first[1])
+
+	// the same seed yields the same delays
+	mrClient.SetSeed(1)
+	assert.Equal(t, first, delays())
+
+	assert.Equal(t, time.Second, mrClient.delayFor(MockResp{Delay: time.Second}))
+	assert.Equal(t, 5*time.Millisecond, mrClient.delayFor(MockResp{DelayMin: 5 * time.Millisecond, DelayMax: 5 * time.Millisecond}))
+}
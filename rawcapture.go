@@ -0,0 +1,101 @@
+package mockresponder
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+)
+
+const contextConn = contextKey("conn")
+const contextRawRequest = contextKey("rawRequest")
+
+// SetRawCapture enables or disables capturing the raw bytes of the requests
+// received by plain HTTP servers started via Server(), see CapturedRequest.Raw.
+// The raw bytes hold the request line, the headers in their original order and
+// case and the body as transferred, e.g. chunked.  This allows assertions on
+// the exact wire format of a client.  Requests pipelined on a connection are
+// captured together with the preceding request.
+func (m *MockResponder) SetRawCapture(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&m.rawCapture, v)
+}
+
+// captureRaw prepares the unstarted server for capturing raw requests.
+func (m *MockResponder) captureRaw(s *httptest.Server) {
+	s.Listener = &recordingListener{Listener: s.Listener, m: m}
+	s.Config.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if rc, ok := c.(*recordingConn); ok {
+			return context.WithValue(ctx, contextConn, rc)
+		}
+		return ctx
+	}
+}
+
+// withRawRequest returns the request with the raw bytes received since the
+// previous request on its connection stored in its context.
+func withRawRequest(req *http.Request) *http.Request {
+	rc, ok := req.Context().Value(contextConn).(*recordingConn)
+	if !ok {
+		return req
+	}
+	raw := rc.take()
+	if len(raw) == 0 {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), contextRawRequest, raw))
+}
+
+// rawRequest returns the raw bytes of the request, if captured.
+func rawRequest(req *http.Request) []byte {
+	raw, _ := req.Context().Value(contextRawRequest).([]byte)
+	return raw
+}
+
+// recordingListener accepts connections which record the bytes they read.
+type recordingListener struct {
+	net.Listener
+	m *MockResponder
+}
+
+func (l *recordingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: c, m: l.m}, nil
+}
+
+// recordingConn records the bytes read from the connection while raw capture
+// is enabled.
+type recordingConn struct {
+	net.Conn
+	m   *MockResponder
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 && atomic.LoadInt32(&c.m.rawCapture) == 1 {
+		c.mu.Lock()
+		c.buf.Write(p[:n])
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// take returns the recorded bytes and resets the recording.
+func (c *recordingConn) take() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	raw := append([]byte(nil), c.buf.Bytes()...)
+	c.buf.Reset()
+	return raw
+}
@@ -0,0 +1,47 @@
+package mockresponder
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetRawCapture(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{MockResp{}, MockResp{}, MockResp{}})
+	s := mrClient.Server()
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	send := func(raw string) {
+		_, err := io.WriteString(conn, raw)
+		assert.NoError(t, err)
+		resp, err := http.ReadResponse(r, nil)
+		assert.NoError(t, err)
+		_, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	send("GET /off HTTP/1.1\r\nHost: bla\r\n\r\n")
+	mrClient.SetRawCapture(true)
+	first := "POST /upload HTTP/1.1\r\nhost: bla\r\nX-lower-Case: 1\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"4\r\ndata\r\n0\r\n\r\n"
+	send(first)
+	second := "GET /next HTTP/1.1\r\nHost: bla\r\n\r\n"
+	send(second)
+
+	history := mrClient.History()
+	assert.Len(t, history, 3)
+	assert.Empty(t, history[0].Request.Raw)
+	assert.Equal(t, first, string(history[1].Request.Raw))
+	assert.Equal(t, []byte(`data`), history[1].Request.Body)
+	assert.Equal(t, second, string(history[2].Request.Raw))
+}
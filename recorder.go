@@ -0,0 +1,97 @@
+package mockresponder
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// RecordingResponder forwards requests to an inner Doer, typically a real
+// http.Client, and records the interactions as mocked responses.  The
+// recording can be used to generate fixtures or to verify that the mocked
+// responses used in tests still match the real service.
+type RecordingResponder struct {
+	inner    Doer
+	recorded MockRespList
+	mu       sync.Mutex
+}
+
+var _ Doer = (*RecordingResponder)(nil)
+
+// NewRecordingResponder returns a new recording responder which forwards
+// requests to inner.
+func NewRecordingResponder(inner Doer) *RecordingResponder {
+	return &RecordingResponder{inner: inner}
+}
+
+// Do satisfies the http.Client.Do() interface.  The response body is read
+// completely to record it, the returned response has an equivalent body.  The
+// recorded response matches the method and the exact URL of the request.
+func (r *RecordingResponder) Do(req *http.Request) (*http.Response, error) {
+	var stub MockResp
+	resp, err := r.inner.Do(req)
+	if err != nil {
+		stub.Err = err
+	} else {
+		if resp.Request == nil {
+			resp.Request = req
+		}
+		// a read error is recorded as BodyErr
+		stub, _ = FromResponse(resp)
+	}
+	stub.Method = req.Method
+	stub.URL = exactURL(req.URL.String())
+
+	r.mu.Lock()
+	r.recorded = append(r.recorded, stub)
+	r.mu.Unlock()
+	return resp, err
+}
+
+// Recorded returns the recorded interactions as mocked responses, in the order
+// in which they happened.
+func (r *RecordingResponder) Recorded() MockRespList {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(MockRespList(nil), r.recorded...)
+}
+
+// Verify compares the recorded interactions with the given mocked responses, in
+// order, and returns the differences.  No differences means that the mocked
+// responses match reality.  JSON bodies are compared structurally and their
+// differences are reported per value instead of dumping both bodies.
+func (r *RecordingResponder) Verify(want MockRespList) []string {
+	got := r.Recorded()
+	var diffs []string
+	if len(got) != len(want) {
+		diffs = append(diffs, fmt.Sprintf("recorded %d responses, expected %d", len(got), len(want)))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		g, w := got[i], want[i]
+		if (g.Err == nil) != (w.Err == nil) {
+			diffs = append(diffs, fmt.Sprintf("%d: error is %v, expected %v", i, g.Err, w.Err))
+			continue
+		}
+		if g.Err != nil {
+			continue
+		}
+		code := w.Code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		if g.Code != code {
+			diffs = append(diffs, fmt.Sprintf("%d: status code is %d, expected %d", i, g.Code, code))
+		}
+		if !bytes.Equal(g.Data, w.Data) {
+			d, ok := jsonDiff(g.Data, w.Data)
+			if !ok {
+				d = []string{fmt.Sprintf("is %q, expected %q", g.Data, w.Data)}
+			}
+			for _, line := range d {
+				diffs = append(diffs, fmt.Sprintf("%d: body %s", i, line))
+			}
+		}
+	}
+	return diffs
+}
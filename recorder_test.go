@@ -0,0 +1,85 @@
+package mockresponder
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingResponder(t *testing.T) {
+	upstream, _ := NewMockResponder()
+	upstream.SetData(MockRespList{
+		MockResp{Data: []byte(`{"version": "2.4.1"}`)},
+		MockResp{Code: http.StatusNotFound, Data: []byte(`not found`)},
+		MockResp{Err: errors.New("ka-boom")},
+	})
+	upstream.SetContextPolicy(ContextFallback)
+
+	rec := NewRecordingResponder(upstream)
+	for _, url := range []string{"http://bla/version", "http://bla/x?y=1", "http://bla/err"} {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		resp, err := rec.Do(req)
+		if err != nil {
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.NotEmpty(t, body)
+	}
+
+	recorded := rec.Recorded()
+	assert.Len(t, recorded, 3)
+	assert.Equal(t, `^http://bla/x\?y=1$`, recorded[1].URL)
+	assert.Equal(t, http.MethodGet, recorded[1].Method)
+	assert.Equal(t, http.StatusNotFound, recorded[1].Code)
+	assert.EqualError(t, recorded[2].Err, "ka-boom")
+
+	// the recording must serve the same responses
+	replay, ctx := NewMockResponder()
+	replay.SetData(recorded)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/x?y=1", nil)
+	resp, err := replay.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	assert.Empty(t, rec.Verify(upstream.GetData()))
+	assert.Equal(t, []string{
+		"recorded 3 responses, expected 2",
+		`0: body $.version: unexpected "2.4.1"`,
+		"1: status code is 404, expected 200",
+	}, rec.Verify(MockRespList{
+		MockResp{Data: []byte(`{}`)},
+		MockResp{Data: []byte(`not found`)},
+	}))
+}
+
+func TestRecordingResponder_Method(t *testing.T) {
+	upstream, _ := NewMockResponder()
+	upstream.SetData(MockRespList{
+		MockResp{Method: http.MethodGet, Data: []byte(`device`)},
+		MockResp{Method: http.MethodDelete, Code: http.StatusNoContent},
+	})
+	upstream.SetContextPolicy(ContextFallback)
+
+	rec := NewRecordingResponder(upstream)
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		req, _ := http.NewRequest(method, "http://bla/devices/1", nil)
+		resp, err := rec.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// the recording serves the response of the method, not the first one
+	replay, ctx := NewMockResponder()
+	replay.SetData(rec.Recorded())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, "http://bla/devices/1", nil)
+	resp, err := replay.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
@@ -0,0 +1,47 @@
+package mockresponder
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// rawBody is the body of a response which replays recorded wire data.
+type rawBody struct {
+	io.ReadCloser
+	raw []byte
+}
+
+// rawResponse parses the recorded wire data of a response.
+func rawResponse(req *http.Request, raw []byte) (*http.Response, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw response: %w", err)
+	}
+	resp.Body = rawBody{ReadCloser: resp.Body, raw: raw}
+	return resp, nil
+}
+
+// replay writes the recorded wire data of the response byte for byte to the
+// connection and closes it.  It returns false if the response has no recorded
+// wire data or if the connection can't be taken over, as with HTTP/2.
+func replay(w http.ResponseWriter, resp *http.Response) bool {
+	rb, ok := resp.Body.(rawBody)
+	if !ok {
+		return false
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return false
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	buf.Write(rb.raw)
+	buf.Flush()
+	return true
+}
@@ -0,0 +1,53 @@
+package mockresponder
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const rawQuirky = "HTTP/1.1 200 OK\r\n" +
+	"x-lower-case: yes\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"SERVER: quirky/1.0\r\n" +
+	"Content-Length: 2\r\n" +
+	"\r\n" +
+	"OK"
+
+func TestMockResponder_Raw(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Raw: []byte(rawQuirky)},
+		MockResp{Raw: []byte(rawQuirky)},
+		MockResp{Raw: []byte("garbage")},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/bla", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "OK", string(body))
+	assert.Equal(t, "yes", resp.Header.Get("X-Lower-Case"))
+
+	// in server mode, the wire data is replayed byte for byte
+	s := mrClient.Server()
+	defer s.Close()
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /bla HTTP/1.1\r\nHost: bla\r\n\r\n"))
+	assert.NoError(t, err)
+	wire, err := io.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, rawQuirky, string(wire))
+
+	_, err = mrClient.Do(req)
+	assert.EqualError(t, err, "invalid raw response: malformed HTTP response \"garbage\"")
+
+	assert.EqualError(t, MockResp{Raw: []byte(rawQuirky), Code: http.StatusOK}.Validate(),
+		"raw response (Raw) is ambiguous with response fields Code")
+}
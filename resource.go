@@ -0,0 +1,167 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resourceIDField is the JSON field which holds the identifier of a created
+// resource.
+const resourceIDField = "id"
+
+// createResource stores the resource created by the request served by data,
+// which creates a resource, and returns the response to serve.  The identifier
+// is taken from the id field of the JSON request body or generated.
+func (m *MockResponder) createResource(req *http.Request, data MockResp) MockResp {
+	body := payload(req)
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		obj = nil
+	}
+
+	id := ""
+	if v, ok := obj[resourceIDField]; ok {
+		id = strings.Trim(jsonString(v), `"`)
+	} else {
+		m.lastResourceID++
+		id = strconv.Itoa(m.lastResourceID)
+		if obj != nil {
+			obj[resourceIDField] = id
+			// a decoded object can always be marshalled
+			body, _ = json.Marshal(obj)
+		}
+	}
+	path := strings.ReplaceAll(data.Creates, "{id}", id)
+	m.storeResource(path, body)
+	m.logf("created resource %s", path)
+
+	if data.Code == 0 {
+		data.Code = http.StatusCreated
+	}
+	if len(data.Data) == 0 {
+		data.Data = body
+	}
+	data.Header = data.Header.Clone()
+	if data.Header == nil {
+		data.Header = make(http.Header)
+	}
+	data.Header.Set("Location", path)
+	return data
+}
+
+// resourceResp returns the response of a GET, PUT or DELETE request for a
+// created resource.  A GET request for a resource which was created but isn't
+// visible yet fails with 404 Not Found, see SetVisibilityLag.
+func (m *MockResponder) resourceResp(req *http.Request) (MockResp, bool) {
+	if !m.hasResource(req) {
+		return MockResp{}, false
+	}
+	path := req.URL.Path
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	switch req.Method {
+	case http.MethodGet:
+		body, ok := m.readResource(path)
+		if !ok {
+			return MockResp{Code: http.StatusNotFound}, true
+		}
+		return MockResp{Data: body, Header: header}, true
+	case http.MethodPut:
+		body := payload(req)
+		m.storeResource(path, body)
+		return MockResp{Data: body, Header: header}, true
+	case http.MethodDelete:
+		delete(m.resources, path)
+		delete(m.pending, path)
+		return NoContentResp(), true
+	}
+	return MockResp{}, false
+}
+
+// hasResource returns true if the request is a GET, PUT or DELETE request for
+// a created resource.
+func (m *MockResponder) hasResource(req *http.Request) bool {
+	_, stored := m.resources[req.URL.Path]
+	_, pending := m.pending[req.URL.Path]
+	if !stored && !pending {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// resourceWrite is a write of a resource which isn't visible yet.
+type resourceWrite struct {
+	body    []byte
+	visible time.Time
+	reads   int
+}
+
+// SetVisibilityLag simulates an eventually consistent store of the resources
+// created by responses with Creates set.  Creating or replacing a resource
+// only becomes visible to GET requests once the lag has passed, measured on
+// the responder's clock, or after the given number of reads, whichever comes
+// first, a zero lag or number of reads disables the respective condition.
+// Until then, reads return the previous version of the resource or fail
+// with 404 Not Found.  Deleting a resource is immediately visible.  By
+// default, writes are immediately visible.
+func (m *MockResponder) SetVisibilityLag(lag time.Duration, reads int) {
+	m.mu.Lock()
+	m.visibilityLag = lag
+	m.visibilityReads = reads
+	m.mu.Unlock()
+}
+
+// storeResource writes the resource at path, subject to the visibility lag.
+func (m *MockResponder) storeResource(path string, body []byte) {
+	if m.visibilityLag <= 0 && m.visibilityReads <= 0 {
+		if m.resources == nil {
+			m.resources = make(map[string][]byte)
+		}
+		m.resources[path] = body
+		return
+	}
+	if m.pending == nil {
+		m.pending = make(map[string]resourceWrite)
+	}
+	m.pending[path] = resourceWrite{body: body, visible: m.now().Add(m.visibilityLag)}
+}
+
+// readResource returns the visible version of the resource at path and counts
+// the read towards the visibility of a pending write.
+func (m *MockResponder) readResource(path string) ([]byte, bool) {
+	if w, ok := m.pending[path]; ok {
+		w.reads++
+		if (m.visibilityLag > 0 && !m.now().Before(w.visible)) ||
+			(m.visibilityReads > 0 && w.reads > m.visibilityReads) {
+			if m.resources == nil {
+				m.resources = make(map[string][]byte)
+			}
+			m.resources[path] = w.body
+			delete(m.pending, path)
+		} else {
+			m.pending[path] = w
+		}
+	}
+	body, ok := m.resources[path]
+	return body, ok
+}
+
+// Resource returns the body of the resource at the given path which was
+// created by a response with Creates set, false if there's no such resource.
+// Writes which aren't visible to the client yet are included, see
+// SetVisibilityLag.
+func (m *MockResponder) Resource(path string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w, ok := m.pending[path]; ok {
+		return w.body, true
+	}
+	body, ok := m.resources[path]
+	return body, ok
+}
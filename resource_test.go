@@ -0,0 +1,103 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_Creates(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Method: http.MethodPost, URL: "/devices$", Creates: "/devices/{id}"},
+		MockResp{Method: http.MethodPost, URL: "/devices$", Creates: "/devices/{id}"},
+	})
+	mrClient.SetFallback(MockResp{Code: http.StatusNotFound})
+
+	call := func(method, url, body string) (*http.Response, string) {
+		req, _ := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	resp, body := call(http.MethodPost, "/devices", `{"name": "r1"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/devices/1", resp.Header.Get("Location"))
+	assert.JSONEq(t, `{"id": "1", "name": "r1"}`, body)
+
+	resp, _ = call(http.MethodPost, "/devices", `{"id": "sw-7", "name": "s7"}`)
+	assert.Equal(t, "/devices/sw-7", resp.Header.Get("Location"))
+
+	_, body = call(http.MethodGet, "/devices/1", "")
+	assert.JSONEq(t, `{"id": "1", "name": "r1"}`, body)
+	_, body = call(http.MethodPut, "/devices/1", `{"id": "1", "name": "r1-new"}`)
+	assert.JSONEq(t, `{"id": "1", "name": "r1-new"}`, body)
+	stored, ok := mrClient.Resource("/devices/1")
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"id": "1", "name": "r1-new"}`, string(stored))
+
+	resp, _ = call(http.MethodDelete, "/devices/1", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = call(http.MethodGet, "/devices/1", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	_, ok = mrClient.Resource("/devices/sw-7")
+	assert.True(t, ok)
+	assert.Len(t, mrClient.Unexpected(), 1)
+}
+
+func TestMockResponder_SetVisibilityLag(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mrClient.SetClock(func() time.Time { return now })
+	mrClient.SetData(MockRespList{
+		MockResp{Method: http.MethodPost, URL: "/devices$", Creates: "/devices/{id}"},
+		MockResp{Method: http.MethodPost, URL: "/devices$", Creates: "/devices/{id}"},
+	})
+
+	call := func(method, url, body string) (int, string) {
+		req, _ := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	// visible after two stale reads
+	mrClient.SetVisibilityLag(0, 2)
+	code, _ := call(http.MethodPost, "/devices", `{"name": "r1"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	stored, ok := mrClient.Resource("/devices/1")
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"id": "1", "name": "r1"}`, string(stored))
+	code, _ = call(http.MethodGet, "/devices/1", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = call(http.MethodGet, "/devices/1", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, body := call(http.MethodGet, "/devices/1", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"id": "1", "name": "r1"}`, body)
+
+	// visible after a second, reads return the previous version until then
+	mrClient.SetVisibilityLag(time.Second, 0)
+	call(http.MethodPut, "/devices/1", `{"id": "1", "name": "r1-new"}`)
+	_, body = call(http.MethodGet, "/devices/1", "")
+	assert.JSONEq(t, `{"id": "1", "name": "r1"}`, body)
+	now = now.Add(time.Second)
+	_, body = call(http.MethodGet, "/devices/1", "")
+	assert.JSONEq(t, `{"id": "1", "name": "r1-new"}`, body)
+
+	// deleting is immediately visible
+	call(http.MethodPost, "/devices", `{"id": "sw-7"}`)
+	code, _ = call(http.MethodDelete, "/devices/sw-7", "")
+	assert.Equal(t, http.StatusNoContent, code)
+	_, ok = mrClient.Resource("/devices/sw-7")
+	assert.False(t, ok)
+}
@@ -0,0 +1,85 @@
+package mockresponder
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"time"
+)
+
+const (
+	// maxPatternInsts is the size of a compiled pattern above which matching
+	// is considered slow.
+	maxPatternInsts = 2000
+	// slowMatch is the duration of a single match above which a warning is
+	// logged.
+	slowMatch = 10 * time.Millisecond
+)
+
+// RiskyPatterns returns a warning for every regular expression of the
+// responses which is likely to make matching slow, like nested repetitions
+// or patterns which compile to very large programs.  Matching runs for every
+// unserved response on every request, one bad pattern slows down the whole
+// suite.  While Go's regexp package matches in linear time, constructs like
+// "(a+)+" are a sign of a mistake and catastrophic in backtracking engines,
+// e.g. when the fixtures are shared with other tools.
+func (l MockRespList) RiskyPatterns() []string {
+	var warnings []string
+	for i, mr := range l {
+		for _, p := range mr.patterns() {
+			if risk := patternRisk(p); len(risk) > 0 {
+				warnings = append(warnings, fmt.Sprintf("response %d (%q): pattern %q %s", i, mr.URL, p, risk))
+			}
+		}
+	}
+	return warnings
+}
+
+// patternRisk returns why the pattern is likely to be slow, empty if it isn't
+// or if it's invalid.
+func patternRisk(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	if nestedRepeat(re, false) {
+		return "has nested unbounded repetitions"
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return ""
+	}
+	if n := len(prog.Inst); n > maxPatternInsts {
+		return fmt.Sprintf("compiles to %d instructions", n)
+	}
+	return ""
+}
+
+// nestedRepeat reports whether the expression holds an unbounded repetition
+// within another one, e.g. "(a+)+" or "(.*x)*".
+func nestedRepeat(re *syntax.Regexp, inRepeat bool) bool {
+	unbounded := re.Op == syntax.OpStar || re.Op == syntax.OpPlus ||
+		(re.Op == syntax.OpRepeat && re.Max == -1)
+	if unbounded && inRepeat {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if nestedRepeat(sub, inRepeat || unbounded) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMatchTime logs a warning once per pattern if a match which started at
+// start took longer than slowMatch.
+func (m *MockResponder) checkMatchTime(pattern string, start time.Time) {
+	d := time.Since(start)
+	if d < slowMatch || m.slowPatterns[pattern] {
+		return
+	}
+	if m.slowPatterns == nil {
+		m.slowPatterns = make(map[string]bool)
+	}
+	m.slowPatterns[pattern] = true
+	m.logf("warning: matching pattern %q took %s", pattern, d)
+}
@@ -0,0 +1,61 @@
+package mockresponder
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_patternRisk(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`^/devices/[0-9]+$`, ""},
+		{`/api/.*/ports`, ""},
+		{`(a+)+$`, "has nested unbounded repetitions"},
+		{`^(/[a-z]*)*$`, "has nested unbounded repetitions"},
+		{`(a{1,3}){2}`, ""},
+		{`[a-z]{1000}[0-9]{1000}x{1000}`, "compiles to 3002 instructions"},
+		{`(`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, patternRisk(tt.pattern))
+		})
+	}
+}
+
+func TestMockRespList_RiskyPatterns(t *testing.T) {
+	l := MockRespList{
+		MockResp{URL: "/devices$"},
+		MockResp{URL: "/ports$", BodyPattern: `("[a-z]+",?)*`},
+	}
+	assert.Equal(t, []string{
+		`response 1 ("/ports$"): pattern "(\"[a-z]+\",?)*" has nested unbounded repetitions`,
+	}, l.RiskyPatterns())
+
+	var logged []string
+	mrClient, _ := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	mrClient.SetData(l)
+	assert.Contains(t, logged, "warning: "+l.RiskyPatterns()[0])
+}
+
+func TestMockResponder_checkMatchTime(t *testing.T) {
+	var logged []string
+	mrClient, _ := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	mrClient.checkMatchTime("fast", time.Now())
+	mrClient.checkMatchTime("slow", time.Now().Add(-time.Second))
+	mrClient.checkMatchTime("slow", time.Now().Add(-time.Second))
+	assert.Len(t, logged, 1)
+	assert.True(t, strings.HasPrefix(logged[0], `warning: matching pattern "slow" took 1`))
+}
@@ -0,0 +1,79 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// schemaID identifies the fixture schema.
+const schemaID = "https://github.com/rschmied/mockresponder/fixtures.schema.json"
+
+// FixtureSchema returns the JSON Schema (draft 2020-12) of the fixture files
+// read by ParseFixtures and LoadFixtures.  Tools in other languages can use it
+// to validate fixture files.  The schema applies to the fixtures after the
+// template has been executed.  It is stricter than ParseFixtures, which
+// ignores unknown fields and matches field names regardless of case: only the
+// documented field names are allowed, which catches misspelled fields.
+func FixtureSchema() []byte {
+	schema := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         schemaID,
+		"title":       "mockresponder fixtures",
+		"description": "A list of mocked responses.",
+		"type":        "array",
+		"items":       schemaType(reflect.TypeOf(fixture{})),
+	}
+	// marshalling maps of strings can't fail
+	data, _ := json.MarshalIndent(schema, "", "  ")
+	return data
+}
+
+// schemaType returns the JSON Schema of values of type t in a fixture.
+func schemaType(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		// any JSON value
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int:
+		return map[string]any{"type": "integer"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": schemaType(t.Elem()),
+		}
+	case reflect.Slice:
+		return map[string]any{
+			"type":  "array",
+			"items": schemaType(t.Elem()),
+		}
+	case reflect.Pointer:
+		return schemaType(t.Elem())
+	case reflect.Struct:
+		props := make(map[string]any)
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			props[name] = schemaType(field.Type)
+			if opts != "omitempty" {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	panic("unsupported fixture field type " + t.String())
+}
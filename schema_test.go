@@ -0,0 +1,76 @@
+package mockresponder
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixtureSchema(t *testing.T) {
+	var schema struct {
+		Schema string `json:"$schema"`
+		Type   string `json:"type"`
+		Items  struct {
+			Type                 string                    `json:"type"`
+			Properties           map[string]map[string]any `json:"properties"`
+			AdditionalProperties *bool                     `json:"additionalProperties"`
+		} `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(FixtureSchema(), &schema))
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema.Schema)
+	assert.Equal(t, "array", schema.Type)
+	assert.Equal(t, "object", schema.Items.Type)
+	// misspelled fields are rejected
+	if assert.NotNil(t, schema.Items.AdditionalProperties) {
+		assert.False(t, *schema.Items.AdditionalProperties)
+	}
+
+	// every fixture field is described
+	ft := reflect.TypeOf(fixture{})
+	assert.Len(t, schema.Items.Properties, ft.NumField())
+	for i := 0; i < ft.NumField(); i++ {
+		name, _, _ := strings.Cut(ft.Field(i).Tag.Get("json"), ",")
+		assert.Contains(t, schema.Items.Properties, name)
+	}
+
+	props := schema.Items.Properties
+	assert.Equal(t, map[string]any{"type": "string"}, props["url"])
+	assert.Equal(t, map[string]any{"type": "integer"}, props["code"])
+	assert.Equal(t, map[string]any{"type": "boolean"}, props["glob"])
+	assert.Equal(t, map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+	}, props["header"])
+	assert.Equal(t, map[string]any{}, props["json"])
+}
+
+func TestFixtureSchema_MockResp(t *testing.T) {
+	// fields which can't be written in a fixture file
+	code := map[string]bool{
+		"Matcher": true, "CheckContext": true, "DataFunc": true,
+		"BodyReader": true, "Respond": true,
+	}
+	aliases := map[string]string{"Data": "body", "Err": "error", "BodyErr": "bodyError"}
+
+	names := make(map[string]bool)
+	ft := reflect.TypeOf(fixture{})
+	for i := 0; i < ft.NumField(); i++ {
+		name, _, _ := strings.Cut(ft.Field(i).Tag.Get("json"), ",")
+		names[strings.ToLower(name)] = true
+	}
+	mt := reflect.TypeOf(MockResp{})
+	for i := 0; i < mt.NumField(); i++ {
+		field := mt.Field(i)
+		if !field.IsExported() || code[field.Name] {
+			continue
+		}
+		name := field.Name
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+		assert.True(t, names[strings.ToLower(name)], "MockResp.%s is missing in the fixtures", field.Name)
+	}
+}
@@ -0,0 +1,46 @@
+package mockresponder
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SeedEnv is the environment variable which sets the seed of new responders,
+// e.g. to reproduce a flaky run with the seed logged by NewMockResponderT.
+const SeedEnv = "MOCKRESPONDER_SEED"
+
+// initSeed seeds the responder from SeedEnv or, if unset, from the time.
+func (m *MockResponder) initSeed() {
+	seed := time.Now().UnixNano()
+	if v := os.Getenv(SeedEnv); len(v) > 0 {
+		s, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			m.logf("warning: ignoring invalid %s %q: %s", SeedEnv, v, err)
+		} else {
+			seed = s
+		}
+	}
+	m.seed = seed
+	m.rng = rand.New(rand.NewSource(seed))
+}
+
+// SetSeed sets the seed of all randomized features of the responder, the
+// weighted latencies of the latency profiles, the random delays between
+// DelayMin and DelayMax and the injected failures of SetFailRate, so that a
+// run can be reproduced.  By default, the seed is taken from SeedEnv or the
+// time.
+func (m *MockResponder) SetSeed(seed int64) {
+	m.mu.Lock()
+	m.seed = seed
+	m.rng = rand.New(rand.NewSource(seed))
+	m.mu.Unlock()
+}
+
+// Seed returns the seed of the randomized features of the responder.
+func (m *MockResponder) Seed() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.seed
+}
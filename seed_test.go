@@ -0,0 +1,24 @@
+package mockresponder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_Seed(t *testing.T) {
+	t.Setenv(SeedEnv, "42")
+	mrClient, _ := NewMockResponder()
+	assert.Equal(t, int64(42), mrClient.Seed())
+	first := mrClient.rng.Int63()
+
+	mrClient.SetSeed(42)
+	assert.Equal(t, first, mrClient.rng.Int63())
+	mrClient.SetSeed(7)
+	assert.Equal(t, int64(7), mrClient.Seed())
+
+	// an invalid seed falls back to a random one
+	t.Setenv(SeedEnv, "nope")
+	mrClient, _ = NewMockResponder()
+	assert.NotNil(t, mrClient.rng)
+}
@@ -0,0 +1,76 @@
+package mockresponder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// sequenceToken returns the sequence token of the request as configured in the
+// response, the key identifies the token across requests.
+func sequenceToken(data *MockResp, req *http.Request) (key, token string, ok bool) {
+	if len(data.SeqHeader) > 0 {
+		key = "header " + http.CanonicalHeaderKey(data.SeqHeader)
+		token = req.Header.Get(data.SeqHeader)
+		return key, token, len(token) > 0
+	}
+	key = "field " + data.SeqField
+	d := json.NewDecoder(bytes.NewReader(payload(req)))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return key, "", false
+	}
+	for _, name := range strings.Split(data.SeqField, ".") {
+		obj, isObj := v.(map[string]any)
+		if !isObj {
+			return key, "", false
+		}
+		if v, ok = obj[name]; !ok {
+			return key, "", false
+		}
+	}
+	switch t := v.(type) {
+	case json.Number:
+		return key, t.String(), true
+	case string:
+		return key, t, true
+	}
+	return key, "", false
+}
+
+// greater returns true if token a is greater than b, numerically if both are
+// integers, lexically otherwise.
+func greater(a, b string) bool {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return x > y
+	}
+	return a > b
+}
+
+// checkSequence records a violation unless the sequence token of the request
+// served by data is strictly greater than the token of the previous request
+// served by a response checking the same token.
+func (m *MockResponder) checkSequence(data *MockResp, req *http.Request) {
+	if len(data.SeqHeader) == 0 && len(data.SeqField) == 0 {
+		return
+	}
+	key, token, ok := sequenceToken(data, req)
+	where := fmt.Sprintf("request %s %s", req.Method, sanitizeURL(req.URL.String()))
+	if !ok {
+		m.violate("%s has no sequence token (%s)", where, key)
+		return
+	}
+	if last, seen := m.sequences[key]; seen && !greater(token, last) {
+		m.violate("%s has sequence token %q (%s) which is not greater than the previous %q", where, token, key, last)
+	}
+	if m.sequences == nil {
+		m.sequences = make(map[string]string)
+	}
+	m.sequences[key] = token
+}
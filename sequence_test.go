@@ -0,0 +1,40 @@
+package mockresponder
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_checkSequence(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	stub := MockResp{SeqHeader: "X-Seq"}
+	update := MockResp{URL: "update$", SeqField: "meta.version"}
+	mrClient.SetData(MockRespList{update, update, stub, stub, stub})
+
+	send := func(url, seq, body string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+		if len(seq) > 0 {
+			req.Header.Set("X-Seq", seq)
+		}
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	send("/update", "", `{"meta": {"version": 9}}`)
+	send("/update", "", `{"meta": {"version": 10}}`)
+	send("/bla", "9", "")
+	send("/bla", "10", "")
+	assert.Empty(t, mrClient.Violations())
+
+	send("/bla", "10", "")
+	assert.Equal(t, []string{
+		`request POST /bla has sequence token "10" (header X-Seq) which is not greater than the previous "10"`,
+	}, mrClient.Violations())
+
+	mrClient.ResetAll()
+	send("/update", "", `{"version": 1}`)
+	assert.Equal(t, []string{"request POST /update has no sequence token (field meta.version)"}, mrClient.Violations())
+}
@@ -0,0 +1,229 @@
+package mockresponder
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// HTTPVersion selects the HTTP protocol version(s) a TLS server offers during
+// ALPN negotiation.
+type HTTPVersion int
+
+const (
+	// HTTPAny offers both HTTP/2 and HTTP/1.1.
+	HTTPAny HTTPVersion = iota
+	// HTTP1 forces HTTP/1.1.
+	HTTP1
+	// HTTP2 forces HTTP/2.
+	HTTP2
+)
+
+// TLSOptions configure a server started via TLSServer.
+type TLSOptions struct {
+	// Hosts are the DNS names and IP addresses the server certificate is
+	// valid for.  Defaults to localhost, 127.0.0.1 and ::1.
+	Hosts []string
+	// HTTPVersion selects the protocol version(s) offered by the server.
+	HTTPVersion HTTPVersion
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// Server starts a test HTTP server which serves the mocked responses.  The
+// requests received by the server are matched against the scheme and host of
+// the server as well as the path and query of the request.  A response with
+// an error aborts the connection.  The server answers GET requests to
+// HealthPath and StubsPath itself.  Servers are closed when the responder is
+// closed.
+func (m *MockResponder) Server() *httptest.Server {
+	s := httptest.NewUnstartedServer(http.HandlerFunc(m.serveHTTP))
+	m.captureRaw(s)
+	s.Start()
+	m.addServer(s)
+	return s
+}
+
+// addServer registers a server started by the responder so that it is closed
+// together with the responder.
+func (m *MockResponder) addServer(s *httptest.Server) {
+	m.mu.Lock()
+	m.servers = append(m.servers, s)
+	m.mu.Unlock()
+}
+
+// TLSServer starts a test HTTPS server which serves the mocked responses.  The
+// server certificate is issued by a test CA which is generated once per
+// responder, see CACertificate() and CertPool() to configure clients to trust
+// it.  The Client() of the returned server trusts the certificate as well.
+// Servers are closed when the responder is closed.
+func (m *MockResponder) TLSServer(opts TLSOptions) (*httptest.Server, error) {
+	hosts := opts.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{"localhost", "127.0.0.1", "::1"}
+	}
+	cert, err := m.issueCertificate(hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(m.serveHTTP))
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	switch opts.HTTPVersion {
+	case HTTP1:
+		s.TLS.NextProtos = []string{"http/1.1"}
+	case HTTP2:
+		s.TLS.NextProtos = []string{"h2"}
+		s.EnableHTTP2 = true
+	default:
+		s.TLS.NextProtos = []string{"h2", "http/1.1"}
+		s.EnableHTTP2 = true
+	}
+	s.StartTLS()
+	m.addServer(s)
+	return s, nil
+}
+
+// CACertificate returns the certificate of the test CA which issues the
+// certificates of servers started via TLSServer.
+func (m *MockResponder) CACertificate() (*x509.Certificate, error) {
+	ca, err := m.testCA()
+	if err != nil {
+		return nil, err
+	}
+	return ca.cert, nil
+}
+
+// CertPool returns a certificate pool which contains the test CA certificate,
+// suitable as RootCAs of a client TLS configuration.
+func (m *MockResponder) CertPool() (*x509.CertPool, error) {
+	cert, err := m.CACertificate()
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool, nil
+}
+
+// testCA returns the test CA of the responder, generating it if needed.
+func (m *MockResponder) testCA() (*testCA, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ca != nil {
+		return m.ca, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mockresponder test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour * 24),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	m.ca = &testCA{cert: cert, key: key}
+	return m.ca, nil
+}
+
+// issueCertificate returns a server certificate for the given hosts signed by
+// the test CA.
+func (m *MockResponder) issueCertificate(hosts []string) (tls.Certificate, error) {
+	ca, err := m.testCA()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour * 24),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der, ca.cert.Raw}, PrivateKey: key}, nil
+}
+
+// serveHTTP serves a request received in server mode via the responder.
+func (m *MockResponder) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.serveAdmin(w, r) {
+		return
+	}
+	// trailers are only available once the body was read and they aren't
+	// carried over by a clone
+	readBody(r)
+	r = withRawRequest(r)
+	r = m.attach(r.Clone(r.Context()))
+	r.URL.Scheme = "http"
+	if r.TLS != nil {
+		r.URL.Scheme = "https"
+	}
+	r.URL.Host = r.Host
+	r = withInformational(w, r)
+
+	resp, err := m.Do(r)
+	if err != nil {
+		// there's no way to return an error to the client other than
+		// aborting the connection
+		panic(http.ErrAbortHandler)
+	}
+	defer resp.Body.Close()
+	if replay(w, resp) {
+		return
+	}
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		// the status and headers have been sent, fail the body
+		panic(http.ErrAbortHandler)
+	}
+}
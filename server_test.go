@@ -0,0 +1,87 @@
+package mockresponder
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_Server(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`OK`), URL: `^http://127\.0\.0\.1:\d+/ok\?q=1$`},
+		MockResp{Err: errors.New("ka-boom")},
+	})
+	s := mrClient.Server()
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL + "/ok?q=1")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []byte(`OK`), body)
+
+	_, err = s.Client().Get(s.URL + "/error")
+	assert.Error(t, err)
+	assert.True(t, mrClient.Empty())
+}
+
+func TestMockResponder_ServerBodyErr(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{Data: []byte(`partial`), Code: http.StatusAccepted, BodyErr: errors.New("ka-boom")},
+	})
+	s := mrClient.Server()
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	_, err = io.ReadAll(resp.Body)
+	assert.Error(t, err)
+}
+
+func TestMockResponder_TLSServer(t *testing.T) {
+	mrClient, _ := NewMockResponder()
+	pool, err := mrClient.CertPool()
+	assert.NoError(t, err)
+	ca, err := mrClient.CACertificate()
+	assert.NoError(t, err)
+	assert.True(t, ca.IsCA)
+
+	tests := []struct {
+		name    string
+		version HTTPVersion
+		want    string
+	}{
+		{"any", HTTPAny, "HTTP/2.0"},
+		{"http1", HTTP1, "HTTP/1.1"},
+		{"http2", HTTP2, "HTTP/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrClient.SetData(MockRespList{
+				MockResp{Data: []byte(`OK`), Scheme: "https", Proto: tt.want},
+			})
+			s, err := mrClient.TLSServer(TLSOptions{HTTPVersion: tt.version})
+			assert.NoError(t, err)
+			defer s.Close()
+
+			client := &http.Client{Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: pool},
+				ForceAttemptHTTP2: true,
+			}}
+			resp, err := client.Get(s.URL)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.want, resp.Proto)
+			assert.True(t, mrClient.Empty())
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package mockresponder
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	soapEnvelopeNS    = "http://schemas.xmlsoap.org/soap/envelope/"
+	soapContentType   = "text/xml; charset=utf-8"
+	soapEnvelopeStart = `<?xml version="1.0" encoding="utf-8"?><soap:Envelope xmlns:soap="` + soapEnvelopeNS + `"><soap:Body>`
+	soapEnvelopeEnd   = `</soap:Body></soap:Envelope>`
+)
+
+// SOAPEnvelope wraps the XML body in a SOAP 1.1 envelope.
+func SOAPEnvelope(body string) []byte {
+	return []byte(soapEnvelopeStart + body + soapEnvelopeEnd)
+}
+
+// SOAPResp returns a SOAP 1.1 response with the given XML wrapped in the body
+// of the envelope, using the text/xml content type.
+func SOAPResp(body string) MockResp {
+	return MockResp{
+		Data:   SOAPEnvelope(body),
+		Header: http.Header{"Content-Type": []string{soapContentType}},
+	}
+}
+
+// SOAPFault returns a SOAP 1.1 fault response with status code 500 and the
+// given fault code (e.g. "soap:Server") and fault string.
+func SOAPFault(code, message string) MockResp {
+	var buf bytes.Buffer
+	buf.WriteString("<soap:Fault><faultcode>")
+	xml.EscapeText(&buf, []byte(code))
+	buf.WriteString("</faultcode><faultstring>")
+	xml.EscapeText(&buf, []byte(message))
+	buf.WriteString("</faultstring></soap:Fault>")
+	mr := SOAPResp(buf.String())
+	mr.Code = http.StatusInternalServerError
+	return mr
+}
+
+// soapAction returns the SOAP action of the request, either from the
+// SOAPAction header (SOAP 1.1) or from the action parameter of the content
+// type (SOAP 1.2).
+func soapAction(req *http.Request) string {
+	if action := req.Header.Get("SOAPAction"); len(action) > 0 {
+		return strings.Trim(action, `"`)
+	}
+	if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil {
+		return params["action"]
+	}
+	return ""
+}
+
+// soapOperation returns the local name of the first element in the body of the
+// SOAP envelope, empty if the body isn't a SOAP envelope.
+func soapOperation(body []byte) string {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	depth := 0
+	inBody := false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1 && el.Name.Local != "Envelope":
+				return ""
+			case depth == 2 && el.Name.Local == "Body":
+				inBody = true
+			case depth == 3 && inBody:
+				return el.Name.Local
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				inBody = false
+			}
+			depth--
+		}
+	}
+}
+
+// mismatchSOAP checks the SOAP action and operation of the request.
+func mismatchSOAP(data *MockResp, req *http.Request) string {
+	if len(data.SOAPAction) > 0 {
+		if action := soapAction(req); action != data.SOAPAction {
+			return fmt.Sprintf("SOAP action %q is not %q", action, data.SOAPAction)
+		}
+	}
+	if len(data.SOAPOperation) > 0 {
+		if op := soapOperation(payload(req)); op != data.SOAPOperation {
+			return fmt.Sprintf("SOAP operation %q is not %q", op, data.SOAPOperation)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSOAPResp(t *testing.T) {
+	mr := SOAPFault("soap:Server", "out of <stock>")
+	assert.Equal(t, http.StatusInternalServerError, mr.Code)
+	assert.Equal(t, soapContentType, mr.Header.Get("Content-Type"))
+	assert.Contains(t, string(mr.Data), "<faultstring>out of &lt;stock&gt;</faultstring>")
+	assert.True(t, strings.HasPrefix(string(mr.Data), `<?xml version="1.0" encoding="utf-8"?><soap:Envelope`))
+}
+
+func TestSOAPMatch(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		SOAPResp("<GetPriceResponse><Price>1.90</Price></GetPriceResponse>"),
+		SOAPResp("<GetQuoteResponse><Price>1.99</Price></GetQuoteResponse>"),
+	})
+	mrClient.mockData[0].SOAPOperation = "GetPrice"
+	mrClient.mockData[1].SOAPAction = "urn:GetQuote"
+
+	call := func(action, op string) string {
+		body := string(SOAPEnvelope("<m:" + op + ` xmlns:m="urn:stock"><m:Symbol>ACME</m:Symbol></m:` + op + ">"))
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/soap", strings.NewReader(body))
+		req.Header.Set("SOAPAction", `"`+action+`"`)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Contains(t, call("urn:GetQuote", "GetQuote"), "1.99")
+	assert.Contains(t, call("urn:GetPrice", "GetPrice"), "1.90")
+}
+
+func Test_soapAction(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/soap", nil)
+	req.Header.Set("Content-Type", `application/soap+xml; charset=utf-8; action="urn:GetQuote"`)
+	assert.Equal(t, "urn:GetQuote", soapAction(req))
+	assert.Equal(t, "", soapOperation([]byte("<html><body><p/></body></html>")))
+}
@@ -0,0 +1,85 @@
+package mockresponder
+
+import "net/http"
+
+// Candidate is an unserved mocked response which matches a request, Index is
+// its position in the list of mocked responses.
+type Candidate struct {
+	Index int
+	Resp  MockResp
+}
+
+// MatchStrategy selects the mocked response which serves a request among the
+// matching unserved responses, see SetMatchStrategy.  The candidates are
+// ordered by descending priority, responses with the same priority keep their
+// order.  Select returns the position of the selected candidate.
+type MatchStrategy interface {
+	Select(req *http.Request, candidates []Candidate) int
+}
+
+// MatchStrategyFunc is a function which satisfies the MatchStrategy interface.
+type MatchStrategyFunc func(req *http.Request, candidates []Candidate) int
+
+// Select returns f(req, candidates).
+func (f MatchStrategyFunc) Select(req *http.Request, candidates []Candidate) int {
+	return f(req, candidates)
+}
+
+var (
+	// PriorityMatch selects the matching response with the highest Priority,
+	// the first one of those with the same priority.  This is the default.
+	PriorityMatch MatchStrategy = MatchStrategyFunc(func(_ *http.Request, _ []Candidate) int {
+		return 0
+	})
+	// FirstMatch selects the first matching response in the list, ignoring
+	// the priorities.
+	FirstMatch MatchStrategy = MatchStrategyFunc(func(_ *http.Request, candidates []Candidate) int {
+		first := 0
+		for i, c := range candidates {
+			if c.Index < candidates[first].Index {
+				first = i
+			}
+		}
+		return first
+	})
+	// MostSpecificMatch selects the matching response with the most request
+	// matchers, e.g. a response for "/devices/1$" with a Method is selected
+	// over a response for "/devices" without, ties are broken by priority.
+	MostSpecificMatch MatchStrategy = MatchStrategyFunc(func(_ *http.Request, candidates []Candidate) int {
+		best := 0
+		for i, c := range candidates {
+			if specificity(c.Resp) > specificity(candidates[best].Resp) {
+				best = i
+			}
+		}
+		return best
+	})
+)
+
+// specificity returns the number of request matchers of the response, a URL
+// pattern counts as a matcher and so does every key of the header, trailer,
+// cookie and form matchers.  Glob only changes how the URL is interpreted and
+// doesn't count.
+func specificity(mr MockResp) int {
+	n := len(mr.matcherParts())
+	if mr.Glob {
+		n--
+	}
+	if len(mr.URL) > 0 {
+		n++
+	}
+	for _, keys := range []int{len(mr.MatchHeaders), len(mr.MatchTrailers), len(mr.MatchCookies), len(mr.MatchForm)} {
+		if keys > 1 {
+			n += keys - 1
+		}
+	}
+	return n
+}
+
+// SetMatchStrategy sets the strategy which selects the response among several
+// matching unserved responses, by default PriorityMatch.
+func (m *MockResponder) SetMatchStrategy(strategy MatchStrategy) {
+	m.mu.Lock()
+	m.strategy = strategy
+	m.mu.Unlock()
+}
@@ -0,0 +1,98 @@
+package mockresponder
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetMatchStrategy(t *testing.T) {
+	data := MockRespList{
+		MockResp{URL: "/devices", Data: []byte(`broad`)},
+		MockResp{URL: "/devices/1$", Method: http.MethodGet, Data: []byte(`specific`)},
+		MockResp{Data: []byte(`urgent`), Priority: 1},
+	}
+	tests := []struct {
+		name     string
+		strategy MatchStrategy
+		want     []string
+	}{
+		{"default", nil, []string{"urgent", "broad", "specific"}},
+		{"priority", PriorityMatch, []string{"urgent", "broad", "specific"}},
+		{"first", FirstMatch, []string{"broad", "specific", "urgent"}},
+		{"most specific", MostSpecificMatch, []string{"specific", "broad", "urgent"}},
+		{"custom", MatchStrategyFunc(func(_ *http.Request, c []Candidate) int {
+			return len(c) - 1
+		}), []string{"specific", "broad", "urgent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrClient, ctx := NewMockResponder()
+			mrClient.SetData(append(MockRespList(nil), data...))
+			mrClient.SetMatchStrategy(tt.strategy)
+			var got []string
+			for range data {
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/devices/1", nil)
+				resp, err := mrClient.Do(req)
+				assert.NoError(t, err)
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				got = append(got, string(body))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMockResponder_SetMatchStrategy_OutOfRange(t *testing.T) {
+	for _, selected := range []int{-1, 2} {
+		mrClient, ctx := NewMockResponder()
+		mrClient.SetData(MockRespList{
+			MockResp{URL: "/devices", Data: []byte(`first`)},
+			MockResp{URL: "/devices", Data: []byte(`second`)},
+		})
+		mrClient.SetMatchStrategy(MatchStrategyFunc(func(*http.Request, []Candidate) int {
+			return selected
+		}))
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/devices", nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "first", string(body))
+		assert.Equal(t, []string{
+			fmt.Sprintf("match strategy selected candidate %d of 2 for request GET http://bla/devices, serving the first", selected),
+		}, mrClient.Violations())
+	}
+}
+
+func TestSpecificity(t *testing.T) {
+	assert.Equal(t, 0, specificity(MockResp{}))
+	assert.Equal(t, 2, specificity(MockResp{URL: "/devices", Method: http.MethodGet}))
+	// values with spaces count once
+	assert.Equal(t, 1, specificity(MockResp{MatchHeaders: map[string]string{"Authorization": "Bearer abc def"}}))
+	assert.Equal(t, 1, specificity(MockResp{BodyPattern: "a b c"}))
+	assert.Equal(t, 1, specificity(MockResp{MatchJSON: map[string]any{"a": 1, "b": "x y"}}))
+	// every header key counts
+	assert.Equal(t, 2, specificity(MockResp{MatchHeaders: map[string]string{"A": "1", "B": "2"}}))
+	// a glob is a URL pattern, not an additional matcher
+	assert.Equal(t, 2, specificity(MockResp{URL: "/devices/*", Glob: true, Method: http.MethodGet}))
+}
+
+func TestMockResponder_MostSpecificMatchGlob(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetMatchStrategy(MostSpecificMatch)
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/devices/[0-9]+$", Method: http.MethodGet, Data: []byte(`regex`)},
+		MockResp{URL: "/devices/*", Glob: true, Method: http.MethodGet, Data: []byte(`glob`)},
+	})
+
+	// equally specific responses are served in order
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/devices/1", nil)
+	idx, ok, _ := mrClient.Match(req)
+	assert.True(t, ok)
+	assert.Equal(t, 0, idx)
+}
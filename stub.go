@@ -0,0 +1,39 @@
+package mockresponder
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StubHeader is the response header which identifies the mocked response that
+// answered a request, see SetStubHeader.
+const StubHeader = "X-Mock-Stub"
+
+// SetStubHeader enables or disables stamping the StubHeader on every response.
+// Its value is the name and index of the mocked response, e.g. "login/3" or
+// "/3" for an unnamed response, and "fallback" for the fallback response.
+// This shows in assertions and captured traffic which response answered a
+// request.  By default, the header is not set.
+func (m *MockResponder) SetStubHeader(enabled bool) {
+	m.mu.Lock()
+	m.stubHeader = enabled
+	m.mu.Unlock()
+}
+
+// stampStub returns the data with the StubHeader set to value if enabled.
+func (m *MockResponder) stampStub(data MockResp, value string) MockResp {
+	if !m.stubHeader {
+		return data
+	}
+	data.Header = data.Header.Clone()
+	if data.Header == nil {
+		data.Header = make(http.Header)
+	}
+	data.Header.Set(StubHeader, value)
+	return data
+}
+
+// stubLabel returns the StubHeader value of the mocked response at idx.
+func (m *MockResponder) stubLabel(idx int) string {
+	return fmt.Sprintf("%s/%d", m.mockData[idx].Name, idx)
+}
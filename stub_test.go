@@ -0,0 +1,51 @@
+package mockresponder
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockResponder_SetStubHeader(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/login$", Name: "login"},
+		MockResp{URL: "/devices$", Header: http.Header{"Content-Type": []string{"application/json"}}},
+		MockResp{URL: "/devices$"},
+	})
+	mrClient.SetFallback(MockResp{Code: http.StatusNotFound})
+
+	get := func(url string) http.Header {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.Header
+	}
+	assert.Empty(t, get("/devices").Get(StubHeader))
+
+	mrClient.SetStubHeader(true)
+	assert.Equal(t, "login/0", get("/login").Get(StubHeader))
+	assert.Equal(t, "/2", get("/devices").Get(StubHeader))
+	assert.Equal(t, "fallback", get("/devices").Get(StubHeader))
+	assert.Nil(t, mrClient.mockData[1].Header.Values(StubHeader))
+}
+
+func TestMockResponder_SetStubHeaderCollapsed(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetStubHeader(true)
+	mrClient.SetCollapseWindow(time.Second)
+	mrClient.SetData(MockRespList{
+		MockResp{URL: "/status$", Name: "status"},
+	})
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/status", nil)
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "status/0", resp.Header.Get(StubHeader))
+	}
+}
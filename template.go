@@ -0,0 +1,88 @@
+package mockresponder
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// templateParam matches a parameter of a path template, e.g. "{id}".
+var templateParam = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templatePattern returns the RegEx of the path template, every parameter
+// matches a single non-empty path segment.
+func templatePattern(template string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range templateParam.FindAllStringSubmatchIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		b.WriteString("(?P<" + template[loc[2]:loc[3]] + ">[^/]+)")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("$")
+	return b.String()
+}
+
+// MatchPathTemplate matches the path against the template, e.g.
+// "/devices/{id}/ports/{port}", and returns the captured parameters.  Every
+// parameter matches a single non-empty path segment.
+func MatchPathTemplate(template, path string) (map[string]string, bool) {
+	re := regexp.MustCompile(templatePattern(template))
+	return captures(re, path)
+}
+
+// captures returns the named groups of the RegEx in s.
+func captures(re *regexp.Regexp, s string) (map[string]string, bool) {
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, name := range re.SubexpNames() {
+		if len(name) > 0 {
+			params[name] = match[i]
+		}
+	}
+	return params, true
+}
+
+// pathParams returns the parameters of the path template of the response
+// captured from the request.
+func (m *MockResponder) pathParams(data *MockResp, req *http.Request) map[string]string {
+	pattern := templatePattern(data.PathTemplate)
+	if m.ignoreURLCase {
+		pattern = "(?i)" + pattern
+	}
+	params, _ := captures(m.mustCompile(pattern), req.URL.Path)
+	return params
+}
+
+// expandTemplate returns the response with the parameters of its path template
+// substituted in the body and header values, e.g. "{id}".
+func (m *MockResponder) expandTemplate(req *http.Request, data MockResp) MockResp {
+	params := m.pathParams(&data, req)
+	if len(params) == 0 {
+		return data
+	}
+	expand := func(s string) string {
+		return templateParam.ReplaceAllStringFunc(s, func(p string) string {
+			if v, ok := params[p[1:len(p)-1]]; ok {
+				return v
+			}
+			return p
+		})
+	}
+	data.Data = []byte(expand(string(data.Data)))
+	if len(data.Header) > 0 {
+		header := make(http.Header, len(data.Header))
+		for k, values := range data.Header {
+			for _, v := range values {
+				header[k] = append(header[k], expand(v))
+			}
+		}
+		data.Header = header
+	}
+	return data
+}
@@ -0,0 +1,48 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPathTemplate(t *testing.T) {
+	params, ok := MatchPathTemplate("/devices/{id}/ports/{port}", "/devices/r1/ports/eth0")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"id": "r1", "port": "eth0"}, params)
+
+	_, ok = MatchPathTemplate("/devices/{id}/ports/{port}", "/devices/r1/ports/eth0/stats")
+	assert.False(t, ok)
+	_, ok = MatchPathTemplate("/devices/{id}", "/devices/")
+	assert.False(t, ok)
+	// braces which aren't parameters are literal
+	params, ok = MatchPathTemplate("/a.b/{1x}/{id}", "/a.b/{1x}/42")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"id": "42"}, params)
+}
+
+func TestMockResponder_PathTemplate(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{
+			PathTemplate: "/devices/{id}/ports/{port}",
+			Data:         []byte(`{"device": "{id}", "port": "{port}", "tags": {}}`),
+			Header:       http.Header{"Location": []string{"/devices/{id}"}},
+		},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://bla/devices/r1", nil)
+	assert.Equal(t, `path "/devices/r1" does not match template "/devices/{id}/ports/{port}"`,
+		mrClient.mismatch(&mrClient.mockData[0], req))
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://bla/devices/r1/ports/eth0?all=1", nil)
+	resp, err := mrClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"device": "r1", "port": "eth0", "tags": {}}`, string(body))
+	assert.Equal(t, "/devices/r1", resp.Header.Get("Location"))
+	assert.Equal(t, []string{"/devices/{id}"}, mrClient.GetData()[0].Header["Location"])
+}
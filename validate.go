@@ -0,0 +1,393 @@
+package mockresponder
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// matchers returns a representation of the request matchers of the response
+// other than the URL, empty if there are none.
+func (mr MockResp) matchers() string {
+	return strings.Join(mr.matcherParts(), " ")
+}
+
+// matcherParts returns a representation of every request matcher of the
+// response other than the URL.
+func (mr MockResp) matcherParts() []string {
+	var parts []string
+	if mr.Glob {
+		parts = append(parts, "glob")
+	}
+	if len(mr.PathTemplate) > 0 {
+		parts = append(parts, "template="+mr.PathTemplate)
+	}
+	if len(mr.NotURL) > 0 {
+		parts = append(parts, "notURL="+mr.NotURL)
+	}
+	if mr.Matcher != nil {
+		parts = append(parts, "matcher")
+	}
+	if len(mr.Method) > 0 {
+		parts = append(parts, "method="+strings.ToUpper(mr.Method))
+	}
+	if len(mr.Host) > 0 {
+		parts = append(parts, "host="+strings.ToLower(mr.Host))
+	}
+	if len(mr.Scheme) > 0 {
+		parts = append(parts, "scheme="+mr.Scheme)
+	}
+	if len(mr.Proto) > 0 {
+		parts = append(parts, "proto="+mr.Proto)
+	}
+	if len(mr.MatchHeaders) > 0 {
+		parts = append(parts, fmt.Sprintf("headers=%v", mr.MatchHeaders))
+	}
+	if len(mr.MatchTrailers) > 0 {
+		parts = append(parts, fmt.Sprintf("trailers=%v", mr.MatchTrailers))
+	}
+	if len(mr.MatchCookies) > 0 {
+		parts = append(parts, fmt.Sprintf("cookies=%v", mr.MatchCookies))
+	}
+	// the secrets are redacted as the representation is logged, see
+	// sameMatchers
+	if mr.MatchBasicAuth != nil {
+		parts = append(parts, "basicAuth="+mr.MatchBasicAuth.Username+":***")
+	}
+	if len(mr.MatchBearerToken) > 0 {
+		parts = append(parts, "bearerToken=***")
+	}
+	if len(mr.MatchContentType) > 0 {
+		parts = append(parts, "contentType="+mr.MatchContentType)
+	}
+	if len(mr.BodyPattern) > 0 {
+		parts = append(parts, "body="+mr.BodyPattern)
+	}
+	if mr.MatchBody != nil {
+		parts = append(parts, fmt.Sprintf("body[%s]=%q", mr.BodyComparator, mr.MatchBody))
+	}
+	if mr.MatchJSON != nil {
+		data, _ := marshalJSON(mr.MatchJSON)
+		parts = append(parts, "json="+string(data))
+	}
+	if mr.MatchXML != nil {
+		parts = append(parts, "xml="+string(mr.MatchXML))
+	}
+	if mr.MatchMultipart != nil {
+		parts = append(parts, fmt.Sprintf("multipart=%v", *mr.MatchMultipart))
+	}
+	if len(mr.MatchForm) > 0 {
+		parts = append(parts, "form="+mr.MatchForm.Encode())
+	}
+	if len(mr.SOAPAction) > 0 {
+		parts = append(parts, "soapAction="+mr.SOAPAction)
+	}
+	if len(mr.SOAPOperation) > 0 {
+		parts = append(parts, "soapOperation="+mr.SOAPOperation)
+	}
+	return parts
+}
+
+// patterns returns the regular expressions of the response.
+func (mr MockResp) patterns() []string {
+	var patterns []string
+	if !mr.Glob {
+		patterns = append(patterns, mr.URL)
+	}
+	if len(mr.PathTemplate) > 0 {
+		patterns = append(patterns, templatePattern(mr.PathTemplate))
+	}
+	patterns = append(patterns, mr.NotURL, mr.BodyPattern)
+	maps := []map[string]string{mr.MatchHeaders, mr.MatchTrailers, mr.MatchCookies}
+	if mr.MatchMultipart != nil {
+		maps = append(maps, mr.MatchMultipart.Fields)
+	}
+	for _, values := range maps {
+		for _, p := range values {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// patternError returns an error if one of the patterns of the response is
+// invalid.  Valid regular expressions are compiled and cached.
+func (m *MockResponder) patternError(data *MockResp) error {
+	if data.Glob {
+		if _, err := path.Match(data.URL, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", data.URL, err)
+		}
+	}
+	if len(data.MatchContentType) > 0 {
+		if _, _, err := mime.ParseMediaType(data.MatchContentType); err != nil {
+			return fmt.Errorf("invalid content type %q: %w", data.MatchContentType, err)
+		}
+	}
+	if data.MatchBody != nil {
+		if _, err := bodyComparator(data); err != nil {
+			return err
+		}
+	}
+	if data.MatchXML != nil {
+		if _, err := parseXML(data.MatchXML); err != nil {
+			return fmt.Errorf("invalid XML %q: %w", data.MatchXML, err)
+		}
+	}
+	for _, p := range data.patterns() {
+		if _, err := m.compile(p); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// literal returns the string a URL pattern matches if the pattern matches only
+// a single literal string, ignoring anchors.
+func literal(pattern string) (string, bool) {
+	re, err := regexp.Compile(strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$"))
+	if err != nil {
+		return "", false
+	}
+	return re.LiteralPrefix()
+}
+
+// sameMatchers returns true if the responses have the same request matchers
+// other than the URL, including the redacted password and bearer token.
+func sameMatchers(a, b MockResp) bool {
+	if a.matchers() != b.matchers() || a.MatchBearerToken != b.MatchBearerToken {
+		return false
+	}
+	return a.MatchBasicAuth == nil || *a.MatchBasicAuth == *b.MatchBasicAuth
+}
+
+// shadows returns true if every request matched by the response b is also
+// matched by the broader response a.  Custom matchers can't be compared, a
+// response with a Matcher never shadows another one.
+func shadows(a, b MockResp) bool {
+	if a.Matcher != nil {
+		return false
+	}
+	if len(a.matchers()) > 0 && !sameMatchers(a, b) {
+		return false
+	}
+	if a.URL == b.URL {
+		// identical patterns are a sequence of responses
+		return !sameMatchers(a, b)
+	}
+	if len(a.URL) == 0 {
+		return true
+	}
+	if a.Glob {
+		if strings.ContainsAny(b.URL, `*?[\`) {
+			return false
+		}
+		m, err := path.Match(a.URL, b.URL)
+		return err == nil && m
+	}
+	lit, ok := literal(b.URL)
+	if !ok {
+		return false
+	}
+	m, err := regexp.MatchString(a.URL, lit)
+	return err == nil && m
+}
+
+// Shadowed returns a warning for every response which can only be served once
+// an earlier, broader response which matches the same requests has been
+// served.  Such responses are a frequent source of unconsumed data.
+func (l MockRespList) Shadowed() []string {
+	var warnings []string
+	for j := range l {
+		for i := range l {
+			// responses with a higher priority or defined earlier with
+			// the same priority serve their requests first
+			first := l[i].Priority > l[j].Priority || (l[i].Priority == l[j].Priority && i < j)
+			if first && shadows(l[i], l[j]) {
+				warnings = append(warnings, fmt.Sprintf(
+					"response %d (%q) is shadowed by the broader response %d (%q) which serves its requests first",
+					j, l[j].URL, i, l[i].URL))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// Validate returns an error if the fields of the mocked response are in
+// conflict.  A transport error (Err) can't be combined with fields which
+// describe the response as the response is never returned in this case.
+// Likewise, a raw response (Raw) replaces the status, headers and body, a
+// respond function (Respond) the status and body and a body reader
+// (BodyReader), data file (DataFile) or data function (DataFunc) the body.
+// Responses with status 204 and 304 must not carry a body, the delays must
+// not be negative and DelayMin must not exceed DelayMax.
+func (mr MockResp) Validate() error {
+	if mr.Err != nil {
+		var fields []string
+		if mr.Code != 0 {
+			fields = append(fields, "Code")
+		}
+		if len(mr.Data) > 0 {
+			fields = append(fields, "Data")
+		}
+		if len(mr.Header) > 0 {
+			fields = append(fields, "Header")
+		}
+		if mr.BodyReader != nil {
+			fields = append(fields, "BodyReader")
+		}
+		if len(mr.DataFile) > 0 {
+			fields = append(fields, "DataFile")
+		}
+		if mr.DataFunc != nil {
+			fields = append(fields, "DataFunc")
+		}
+		if mr.Respond != nil {
+			fields = append(fields, "Respond")
+		}
+		if mr.BodyErr != nil {
+			fields = append(fields, "BodyErr")
+		}
+		if mr.BodyHang {
+			fields = append(fields, "BodyHang")
+		}
+		if len(mr.Raw) > 0 {
+			fields = append(fields, "Raw")
+		}
+		if len(mr.Creates) > 0 {
+			fields = append(fields, "Creates")
+		}
+		if len(mr.Languages) > 0 {
+			fields = append(fields, "Languages")
+		}
+		if len(mr.LanguageFallback) > 0 {
+			fields = append(fields, "LanguageFallback")
+		}
+		if len(mr.Informational) > 0 {
+			fields = append(fields, "Informational")
+		}
+		if len(fields) > 0 {
+			return fmt.Errorf("transport error (Err) is ambiguous with response fields %s", strings.Join(fields, ", "))
+		}
+	}
+	if len(mr.Raw) > 0 {
+		var fields []string
+		if mr.Code != 0 {
+			fields = append(fields, "Code")
+		}
+		if len(mr.Header) > 0 {
+			fields = append(fields, "Header")
+		}
+		if len(fields) > 0 {
+			return fmt.Errorf("raw response (Raw) is ambiguous with response fields %s", strings.Join(fields, ", "))
+		}
+	}
+	if mr.Respond != nil && mr.Code != 0 {
+		return errors.New("respond function (Respond) is ambiguous with response fields Code")
+	}
+	if sources := bodySources(mr); len(sources) > 1 {
+		// Languages are variants of Data
+		last := sources[len(sources)-1]
+		if last.field != "Languages" {
+			var fields []string
+			for _, s := range sources[:len(sources)-1] {
+				fields = append(fields, s.field)
+			}
+			return fmt.Errorf("%s (%s) is ambiguous with response fields %s", last.desc, last.field, strings.Join(fields, ", "))
+		}
+	}
+	if mr.Delay < 0 {
+		return fmt.Errorf("negative delay %s", mr.Delay)
+	}
+	if mr.DelayMin < 0 {
+		return fmt.Errorf("negative minimum delay %s", mr.DelayMin)
+	}
+	if mr.DelayMin > mr.DelayMax {
+		return fmt.Errorf("minimum delay %s exceeds the maximum delay %s", mr.DelayMin, mr.DelayMax)
+	}
+	for _, i := range mr.Informational {
+		if i.Code < 100 || i.Code > 199 || i.Code == http.StatusSwitchingProtocols {
+			return fmt.Errorf("status code %d is not an informational response", i.Code)
+		}
+	}
+	if !bodyAllowed(mr.Code) && len(bodySources(mr)) > 0 {
+		return fmt.Errorf("status code %d must not carry a body", mr.Code)
+	}
+	return nil
+}
+
+// bodySource is a field which supplies the body of a mocked response.
+type bodySource struct {
+	field, desc string
+}
+
+// bodySources returns the fields of the response which supply its body, each
+// replacing the ones before it.
+func bodySources(mr MockResp) []bodySource {
+	var sources []bodySource
+	if len(mr.Data) > 0 {
+		sources = append(sources, bodySource{"Data", "data"})
+	}
+	if len(mr.Languages) > 0 {
+		sources = append(sources, bodySource{"Languages", "language variants"})
+	}
+	if mr.BodyReader != nil {
+		sources = append(sources, bodySource{"BodyReader", "body reader"})
+	}
+	if len(mr.DataFile) > 0 {
+		sources = append(sources, bodySource{"DataFile", "data file"})
+	}
+	if mr.DataFunc != nil {
+		sources = append(sources, bodySource{"DataFunc", "data function"})
+	}
+	if len(mr.Raw) > 0 {
+		sources = append(sources, bodySource{"Raw", "raw response"})
+	}
+	if mr.Respond != nil {
+		sources = append(sources, bodySource{"Respond", "respond function"})
+	}
+	return sources
+}
+
+// Validate returns an error describing every mocked response in the list
+// whose fields are in conflict, see MockResp.Validate().
+func (l MockRespList) Validate() error {
+	if problems := l.conflicts(); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// conflicts returns a problem for every mocked response in the list whose
+// fields are in conflict.
+func (l MockRespList) conflicts() []string {
+	var problems []string
+	for i, mr := range l {
+		if err := mr.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("response %d (%q): %s", i, mr.URL, err))
+		}
+	}
+	return problems
+}
+
+// SetStrictValidation sets whether mocked responses with conflicting fields,
+// see MockResp.Validate(), fail registration.  In strict mode, SetDataE returns
+// the conflicts as an error and SetData panics, in both cases the data is not
+// set.  By default, the conflicts are logged and the conflicting fields are
+// partially ignored, e.g. the Code of a response with Err.
+func (m *MockResponder) SetStrictValidation(strict bool) {
+	m.mu.Lock()
+	m.strictValidation = strict
+	m.mu.Unlock()
+}
+
+// isStrict returns true in strict validation mode.
+func (m *MockResponder) isStrict() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.strictValidation
+}
@@ -0,0 +1,153 @@
+package mockresponder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockRespList_Shadowed(t *testing.T) {
+	tests := []struct {
+		name string
+		data MockRespList
+		want []string
+	}{
+		{"none", MockRespList{{URL: "/a$"}, {URL: "/b$"}}, nil},
+		{"sequence", MockRespList{{URL: "/a$"}, {URL: "/a$"}, {}, {}}, nil},
+		{"catchall", MockRespList{{}, {URL: "/a$"}}, []string{
+			`response 1 ("/a$") is shadowed by the broader response 0 ("") which serves its requests first`,
+		}},
+		{"broader", MockRespList{{URL: "devices"}, {URL: "^/api/devices/1$"}}, []string{
+			`response 1 ("^/api/devices/1$") is shadowed by the broader response 0 ("devices") which serves its requests first`,
+		}},
+		{"narrower", MockRespList{{URL: "^/api/devices/1$"}, {URL: "devices"}}, nil},
+		{"regex", MockRespList{{URL: "devices"}, {URL: "devices/[0-9]+$"}}, nil},
+		{"matchers", MockRespList{{Scheme: "https"}, {URL: "/a$"}}, nil},
+		{"samematchers", MockRespList{{Scheme: "https"}, {URL: "/a$", Scheme: "https"}}, []string{
+			`response 1 ("/a$") is shadowed by the broader response 0 ("") which serves its requests first`,
+		}},
+		{"passwords", MockRespList{
+			{MatchBasicAuth: &Credentials{"admin", "secret"}},
+			{URL: "/a$", MatchBasicAuth: &Credentials{"admin", "other"}},
+		}, nil},
+		{"tokens", MockRespList{{MatchBearerToken: "abc"}, {URL: "/a$", MatchBearerToken: "def"}}, nil},
+		{"sametoken", MockRespList{{MatchBearerToken: "abc"}, {URL: "/a$", MatchBearerToken: "abc"}}, []string{
+			`response 1 ("/a$") is shadowed by the broader response 0 ("") which serves its requests first`,
+		}},
+		{"matcher", MockRespList{
+			{Matcher: func(*http.Request) bool { return true }},
+			{URL: "/a$", Matcher: func(*http.Request) bool { return false }},
+		}, nil},
+		{"nomatcher", MockRespList{{}, {URL: "/a$", Matcher: func(*http.Request) bool { return false }}}, []string{
+			`response 1 ("/a$") is shadowed by the broader response 0 ("") which serves its requests first`,
+		}},
+		{"glob", MockRespList{{URL: "/devices/*", Glob: true}, {URL: "/devices/1", Glob: true}}, []string{
+			`response 1 ("/devices/1") is shadowed by the broader response 0 ("/devices/*") which serves its requests first`,
+		}},
+		{"globs", MockRespList{{URL: "/devices/*", Glob: true}, {URL: "/devices/*/ports", Glob: true}}, nil},
+		{"priority", MockRespList{{Priority: -1}, {URL: "/a$"}}, nil},
+		{"higher", MockRespList{{URL: "/a$"}, {Priority: 1}}, []string{
+			`response 0 ("/a$") is shadowed by the broader response 1 ("") which serves its requests first`,
+		}},
+		{"lessmatchers", MockRespList{{URL: "/a$"}, {URL: "/a$", Proto: "HTTP/2.0"}}, []string{
+			`response 1 ("/a$") is shadowed by the broader response 0 ("/a$") which serves its requests first`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.data.Shadowed())
+		})
+	}
+}
+
+func TestMockResp_matchers(t *testing.T) {
+	mr := MockResp{Method: http.MethodGet, MatchBasicAuth: &Credentials{"admin", "secret"}, MatchBearerToken: "token"}
+	assert.Equal(t, "method=GET basicAuth=admin:*** bearerToken=***", mr.matchers())
+}
+
+func TestMockRespList_Validate(t *testing.T) {
+	boom := errors.New("ka-boom")
+	assert.NoError(t, MockRespList{
+		TransportError(boom),
+		ProtocolError(http.StatusNotFound, []byte(`not found`)),
+		{Data: []byte(`OK`)},
+	}.Validate())
+
+	err := MockRespList{
+		{URL: "a", Err: boom, Data: []byte(`NAK`)},
+		TransportError(boom),
+		{URL: "b", Err: boom, Code: http.StatusOK, BodyHang: true},
+	}.Validate()
+	assert.EqualError(t, err, `response 0 ("a"): transport error (Err) is ambiguous with response fields Data; `+
+		`response 2 ("b"): transport error (Err) is ambiguous with response fields Code, BodyHang`)
+
+	assert.NoError(t, NoContentResp().Validate())
+	assert.EqualError(t, MockResp{Code: http.StatusNotModified, Data: []byte(`stale`)}.Validate(),
+		"status code 304 must not carry a body")
+	assert.EqualError(t, MockResp{Delay: -time.Second}.Validate(), "negative delay -1s")
+	assert.EqualError(t, MockResp{DelayMin: -time.Second}.Validate(), "negative minimum delay -1s")
+	assert.EqualError(t, MockResp{DelayMin: 2 * time.Second, DelayMax: time.Second}.Validate(),
+		"minimum delay 2s exceeds the maximum delay 1s")
+	assert.NoError(t, MockResp{DelayMin: time.Second, DelayMax: 2 * time.Second}.Validate())
+}
+
+func TestMockResp_Validate_bodySources(t *testing.T) {
+	dataFunc := func(*http.Request) []byte { return nil }
+	tests := []struct {
+		name string
+		mr   MockResp
+		want string
+	}{
+		{"languages", MockResp{Data: []byte(`hi`), Languages: map[string][]byte{"de": []byte(`hallo`)}}, ""},
+		{"file and func", MockResp{DataFile: "dump.json", DataFunc: dataFunc},
+			"data function (DataFunc) is ambiguous with response fields DataFile"},
+		{"languages and file", MockResp{Data: []byte(`hi`), Languages: map[string][]byte{"de": []byte(`hallo`)}, DataFile: "dump.json"},
+			"data file (DataFile) is ambiguous with response fields Data, Languages"},
+		{"raw", MockResp{Raw: []byte("HTTP/1.1 200 OK\r\n\r\n"), Data: []byte(`hi`)},
+			"raw response (Raw) is ambiguous with response fields Data"},
+		{"error and languages", MockResp{Err: io.EOF, Languages: map[string][]byte{"de": []byte(`hallo`)}, LanguageFallback: "de"},
+			"transport error (Err) is ambiguous with response fields Languages, LanguageFallback"},
+		{"error and informational", MockResp{Err: io.EOF, Informational: []Informational{EarlyHints("</a.css>")}},
+			"transport error (Err) is ambiguous with response fields Informational"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.mr.Validate()
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestMockResponder_SetStrictValidation(t *testing.T) {
+	data := MockRespList{
+		MockResp{URL: "/ok$"},
+		MockResp{URL: "/err$", Err: errors.New("ka-boom"), Code: http.StatusTeapot},
+	}
+	var logged []string
+	mrClient, _ := NewMockResponder()
+	mrClient.SetLogger(func(format string, args ...any) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	mrClient.SetData(data)
+	assert.Contains(t, logged, `warning: response 1 ("/err$"): transport error (Err) is ambiguous with response fields Code`)
+	assert.Len(t, mrClient.GetData(), 2)
+
+	mrClient, _ = NewMockResponder()
+	mrClient.SetStrictValidation(true)
+	assert.EqualError(t, mrClient.SetDataE(data), `response 1 ("/err$"): transport error (Err) is ambiguous with response fields Code`)
+	assert.PanicsWithValue(t, `conflicting mocked responses: response 1 ("/err$"): transport error (Err) is ambiguous with response fields Code`, func() {
+		mrClient.SetData(data)
+	})
+	assert.Empty(t, mrClient.GetData())
+	assert.NoError(t, mrClient.SetDataE(data[:1]))
+	assert.Len(t, mrClient.GetData(), 1)
+}
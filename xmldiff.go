@@ -0,0 +1,158 @@
+package mockresponder
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// xmlNode is an element of a canonical XML document: namespace prefixes are
+// resolved, namespace declarations, comments and processing instructions are
+// dropped, attributes are sorted and whitespace around text is trimmed.
+type xmlNode struct {
+	name     xml.Name
+	attrs    map[string]string
+	text     string
+	children []*xmlNode
+}
+
+// parseXML returns the root element of the canonical XML document.
+func parseXML(data []byte) (*xmlNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name, attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+					continue
+				}
+				n.attrs[xmlName(a.Name)] = a.Value
+			}
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			case root != nil:
+				return nil, errors.New("multiple root elements")
+			default:
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); len(text) > 0 && len(stack) > 0 {
+				stack[len(stack)-1].text += text
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// xmlName returns the name with its resolved namespace, e.g.
+// "{http://example.com/ns}quote".
+func xmlName(n xml.Name) string {
+	if len(n.Space) == 0 {
+		return n.Local
+	}
+	return "{" + n.Space + "}" + n.Local
+}
+
+// xmlDiff returns the differences between the canonical XML documents got and
+// want, each prefixed with the path of the element (e.g.
+// "/Envelope/Body/GetQuote").
+func xmlDiff(got, want []byte) ([]string, error) {
+	w, err := parseXML(want)
+	if err != nil {
+		return nil, fmt.Errorf("expected XML body is invalid: %w", err)
+	}
+	g, err := parseXML(got)
+	if err != nil {
+		return nil, errors.New("body is not XML")
+	}
+	var diffs []string
+	diffNodes("", g, w, &diffs)
+	return diffs, nil
+}
+
+// diffNodes appends the differences between the elements got and want to
+// diffs, path is the path of their parent.
+func diffNodes(path string, got, want *xmlNode, diffs *[]string) {
+	path += "/" + want.name.Local
+	if got.name != want.name {
+		*diffs = append(*diffs, fmt.Sprintf("%s: got element %s, want %s", path, xmlName(got.name), xmlName(want.name)))
+		return
+	}
+	keys := make([]string, 0, len(want.attrs)+len(got.attrs))
+	for k := range want.attrs {
+		keys = append(keys, k)
+	}
+	for k := range got.attrs {
+		if _, ok := want.attrs[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		gv, inGot := got.attrs[k]
+		wv, inWant := want.attrs[k]
+		switch {
+		case !inGot:
+			*diffs = append(*diffs, fmt.Sprintf("%s@%s: missing, want %q", path, k, wv))
+		case !inWant:
+			*diffs = append(*diffs, fmt.Sprintf("%s@%s: unexpected %q", path, k, gv))
+		case gv != wv:
+			*diffs = append(*diffs, fmt.Sprintf("%s@%s: got %q, want %q", path, k, gv, wv))
+		}
+	}
+	if got.text != want.text {
+		*diffs = append(*diffs, fmt.Sprintf("%s: got text %q, want %q", path, got.text, want.text))
+	}
+	if len(got.children) != len(want.children) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: got %d child elements, want %d", path, len(got.children), len(want.children)))
+	}
+	for i := 0; i < len(got.children) && i < len(want.children); i++ {
+		diffNodes(path, got.children[i], want.children[i], diffs)
+	}
+}
+
+// mismatchXML compares the XML body of the request with the expected document.
+func mismatchXML(want []byte, req *http.Request) string {
+	diffs, err := xmlDiff(payload(req), want)
+	if err != nil {
+		return err.Error()
+	}
+	if len(diffs) > 0 {
+		return "XML body differs: " + strings.Join(diffs, "; ")
+	}
+	return ""
+}
+
+func compareXML(got, want []byte) error {
+	diffs, err := xmlDiff(got, want)
+	if err != nil {
+		return err
+	}
+	if len(diffs) > 0 {
+		return errors.New(strings.Join(diffs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package mockresponder
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_xmlDiff(t *testing.T) {
+	want := `<q:quote xmlns:q="urn:quotes" currency="USD" exchange="NYSE"><symbol>ACME</symbol><price>42</price></q:quote>`
+	tests := []struct {
+		name string
+		got  string
+		want []string
+		err  string
+	}{
+		{"equal", want, nil, ""},
+		{"canonical", `<?xml version="1.0"?>
+<!-- quote -->
+<x:quote exchange="NYSE" currency="USD" xmlns:x="urn:quotes">
+  <symbol> ACME </symbol>
+  <price>42</price>
+</x:quote>`, nil, ""},
+		{"differs", `<quote xmlns="urn:quotes" currency="EUR" venue="X"><symbol xmlns="">ACME</symbol></quote>`, []string{
+			`/quote@currency: got "EUR", want "USD"`,
+			`/quote@exchange: missing, want "NYSE"`,
+			`/quote@venue: unexpected "X"`,
+			`/quote: got 1 child elements, want 2`,
+		}, ""},
+		{"namespace", `<quote currency="USD" exchange="NYSE"><symbol>ACME</symbol><price>42</price></quote>`, []string{
+			`/quote: got element quote, want {urn:quotes}quote`,
+		}, ""},
+		{"text", strings.Replace(want, "42", "43", 1), []string{`/quote/price: got text "43", want "42"`}, ""},
+		{"invalid", `{"symbol": "ACME"}`, nil, "body is not XML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := xmlDiff([]byte(tt.got), []byte(want))
+			if len(tt.err) > 0 {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, diffs)
+		})
+	}
+	_, err := xmlDiff([]byte(want), []byte(`<a>`))
+	assert.ErrorContains(t, err, "expected XML body is invalid")
+}
+
+func TestMockResponder_MatchXML(t *testing.T) {
+	mrClient, ctx := NewMockResponder()
+	mrClient.SetData(MockRespList{
+		MockResp{MatchXML: SOAPEnvelope(`<GetQuote xmlns="urn:quotes"><symbol>ACME</symbol></GetQuote>`), Data: []byte(`acme`)},
+		MockResp{MatchBody: []byte(`<a x="1" y="2"/>`), BodyComparator: CompareXML, Data: []byte(`a`)},
+	})
+
+	post := func(body string) string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/soap", strings.NewReader(body))
+		resp, err := mrClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+	assert.Equal(t, "a", post(`<a y="2" x="1"></a>`))
+	assert.Equal(t, "acme", post(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/">
+  <s:Body>
+    <q:GetQuote xmlns:q="urn:quotes"><q:symbol>ACME</q:symbol></q:GetQuote>
+  </s:Body>
+</s:Envelope>`))
+
+	req, _ := http.NewRequest(http.MethodPost, "/soap", strings.NewReader(`<a x="2"/>`))
+	assert.Equal(t, `XML body differs: /a@x: got "2", want "1"`, mrClient.mismatch(&MockResp{MatchXML: []byte(`<a x="1"/>`)}, req))
+}